@@ -15,16 +15,47 @@ type ChatRequest struct {
 	Messages []OpenAIMessage `json:"messages"`
 	// 是否流式
 	Stream bool `json:"stream"`
-	// 温度
-	Temperature float32 `json:"temperature,omitempty"`
-	// 最大tokens
+	// 温度，未设置时使用模型的默认值；0 也会传给模型
+	Temperature *float32 `json:"temperature,omitempty"`
+	// 最大tokens，未设置时使用模型配置的 max_tokens
 	MaxTokens int `json:"max_tokens,omitempty"`
+	// 流式选项
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	// 是否在流式响应中额外推送工具开始、结果、结束事件
+	ToolEvents bool `json:"tool_events,omitempty"`
+	// 是否在响应中返回模型的推理过程（reasoning_content），默认隐藏
+	IncludeReasoning bool `json:"include_reasoning,omitempty"`
+	// 对等待确认的保存操作的答复：approve 或 reject，也可以直接在消息中回复
+	Approval string `json:"approval,omitempty"`
+}
+
+// StreamOptions represents the stream_options of an OpenAI API chat completion request
+type StreamOptions struct {
+	// 是否在流式响应的最后返回token用量
+	IncludeUsage bool `json:"include_usage"`
 }
 
 // OpenAIMessage represents a message in an OpenAI API request or response
 type OpenAIMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []OpenAIToolCall `json:"tool_calls,omitempty"`
+	// 推理模型的思考过程，与最终回答分开返回
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+}
+
+// OpenAIToolCall represents a tool call in an OpenAI API message or stream delta
+type OpenAIToolCall struct {
+	Index    int                `json:"index"`
+	ID       string             `json:"id,omitempty"`
+	Type     string             `json:"type,omitempty"`
+	Function OpenAIFunctionCall `json:"function"`
+}
+
+// OpenAIFunctionCall represents the function invoked by a tool call
+type OpenAIFunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
 }
 
 // ChatResponse represents an OpenAI API chat completion response
@@ -35,6 +66,8 @@ type ChatResponse struct {
 	Model   string               `json:"model"`
 	Choices []ChatResponseChoice `json:"choices"`
 	Usage   ChatResponseUsage    `json:"usage"`
+	// 运行暂停等待用户确认时，返回待确认的工具调用及其将要保存的变更
+	PendingApproval interface{} `json:"pending_approval,omitempty"`
 }
 
 // ChatResponseChoice represents a choice in an OpenAI API response
@@ -58,6 +91,9 @@ type ChatStreamResponse struct {
 	Created int64                      `json:"created"`
 	Model   string                     `json:"model"`
 	Choices []ChatStreamResponseChoice `json:"choices"`
+	Usage   *ChatResponseUsage         `json:"usage,omitempty"`
+	// 运行暂停等待用户确认时，返回待确认的工具调用及其将要保存的变更
+	PendingApproval interface{} `json:"pending_approval,omitempty"`
 }
 
 // ChatStreamResponseChoice represents a choice in a streaming OpenAI API response
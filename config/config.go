@@ -11,12 +11,16 @@ import (
 
 // Config holds the application configuration
 type Config struct {
-	Server     ServerConfig `toml:"server"`
-	OpenAI     OpenAIConfig `toml:"openai"`
-	Chat       ChatConfig   `toml:"chat"`
-	LogPath    string       `toml:"log_path"`
-	MCP        MCPConfig    `toml:"mcp"`
-	HTTPClient HttpClient   `toml:"httpclient"`
+	Server     ServerConfig  `toml:"server"`
+	OpenAI     OpenAIConfig  `toml:"openai"`
+	Models     []ModelConfig `toml:"models"`
+	Chat       ChatConfig    `toml:"chat"`
+	LogPath    string        `toml:"log_path"`
+	Tools      ToolsConfig   `toml:"tools"`
+	Cache      CacheConfig   `toml:"cache"`
+	Module     ModuleConfig  `toml:"module"`
+	MCP        MCPConfig     `toml:"mcp"`
+	HTTPClient HttpClient    `toml:"httpclient"`
 }
 
 // ServerConfig contains server configuration
@@ -35,10 +39,42 @@ type OpenAIConfig struct {
 	MaxTokens int    `toml:"max_tokens"`
 }
 
+// ModelConfig contains configuration for a named chat model backend
+type ModelConfig struct {
+	Name      string `toml:"name"`
+	Provider  string `toml:"provider"`
+	APIKey    string `toml:"api_key"`
+	ModelID   string `toml:"model_id"`
+	BaseURL   string `toml:"base_url"`
+	MaxTokens int    `toml:"max_tokens"`
+}
+
 // ChatConfig contains chat configuration
 type ChatConfig struct {
 	SystemPrompt     string `toml:"system_prompt"`
 	MaxHistoryLength int    `toml:"max_history_length"`
+	MaxHistoryTokens int    `toml:"max_history_tokens"`
+	MaxSteps         int    `toml:"max_steps"`
+	ToolConcurrency  int    `toml:"tool_concurrency"`
+	LateToolCalls    bool   `toml:"late_tool_calls"`
+}
+
+// CacheConfig contains draft storage configuration
+type CacheConfig struct {
+	Backend  string `toml:"backend"`   // memory or bolt
+	Path     string `toml:"path"`      // bolt database file
+	DraftTTL int    `toml:"draft_ttl"` // hours after the last change; 0 uses the backend default, -1 keeps drafts forever
+}
+
+// ModuleConfig contains module configuration editing settings
+type ModuleConfig struct {
+	Layouts map[string][]string `toml:"layouts"` // layouts the renderer offers, by page (table, form)
+}
+
+// ToolsConfig contains local tool runtime configuration
+type ToolsConfig struct {
+	Timeout  int            `toml:"timeout"`  // seconds
+	Timeouts map[string]int `toml:"timeouts"` // seconds, by tool name
 }
 
 // MCPConfig contains Model Control Protocol configuration
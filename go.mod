@@ -12,6 +12,7 @@ require (
 	github.com/gin-contrib/cors v1.7.5
 	github.com/gin-gonic/gin v1.10.0
 	github.com/mark3labs/mcp-go v0.20.1
+	go.etcd.io/bbolt v1.3.11
 )
 
 require (
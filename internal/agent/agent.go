@@ -5,9 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
-	"github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/callbacks"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/prompt"
 	"github.com/cloudwego/eino/compose"
@@ -15,18 +16,41 @@ import (
 
 	"coder/api"
 	"coder/app"
+	"coder/internal/cache"
 	"coder/internal/config"
 	"coder/internal/mcp"
-	"coder/internal/models"
 	"coder/internal/tools"
 	"coder/nodelog"
 )
 
+// defaultMaxSteps is the graph step limit used when chat.max_steps is not configured
+const defaultMaxSteps = 20
+
+// agentState holds the messages exchanged with the model during a single run
+type agentState struct {
+	Messages []*schema.Message
+	// 等待用户确认的工具调用，以及恢复运行时用户的决定
+	PendingToolCalls []schema.ToolCall
+	Approval         string
+}
+
 // Agent represents an Eino agent for chat
 type Agent struct {
-	einoGraph   compose.Runnable[map[string]any, *schema.Message]
+	backends    []*modelBackend
 	mcpManager  *mcp.MCPManager
 	toolManager *tools.ToolManager
+	checkPoints *checkPointStore
+	approvals   *cache.Cache[*PendingApproval]
+}
+
+// run holds everything needed to invoke or stream the graph for one request
+type run struct {
+	ctx          context.Context
+	backend      *modelBackend
+	input        map[string]any
+	usage        *Usage
+	opts         []compose.Option
+	checkPointID string
 }
 
 // New creates a new chat agent
@@ -49,48 +73,103 @@ func New(ctx context.Context) (*Agent, error) {
 		return nil, fmt.Errorf("failed to initialize tool manager: %w", err)
 	}
 
-	// Create the Eino graph with tools
-	einoGraph, err := createEinoGraph(ctx, mcpManager, toolManager)
-	if err != nil {
-		mcpManager.Close() // Clean up MCP connections on error
-		return nil, err
+	// Create one Eino graph with tools for every configured model
+	checkPoints := newCheckPointStore()
+	var backends []*modelBackend
+	for _, cfg := range modelConfigs() {
+		chatModel, err := newChatModel(ctx, cfg)
+		if err != nil {
+			mcpManager.Close() // Clean up MCP connections on error
+			return nil, fmt.Errorf("failed to create chat model %s: %w", cfg.Name, err)
+		}
+
+		einoGraph, err := createEinoGraph(ctx, chatModel, mcpManager, toolManager, checkPoints)
+		if err != nil {
+			mcpManager.Close() // Clean up MCP connections on error
+			return nil, err
+		}
+
+		log.Printf("Registered model %s (%s, %s)", cfg.Name, cfg.Provider, cfg.ModelID)
+		backends = append(backends, &modelBackend{config: cfg, graph: einoGraph})
 	}
 
 	return &Agent{
-		einoGraph:   einoGraph,
+		backends:    backends,
 		mcpManager:  mcpManager,
 		toolManager: toolManager,
+		checkPoints: checkPoints,
+		approvals:   cache.New[*PendingApproval](),
 	}, nil
 }
 
-// Generate generates a single response from the agent
-func (a *Agent) Generate(ctx context.Context, req *api.ChatRequest, systemPrompt string, chatHistory []*schema.Message, userQuery string) (*schema.Message, error) {
-	// Use the graph with branch logic to handle tool calls
-	message, err := a.einoGraph.Invoke(ctx, map[string]any{
-		"system_prompt": systemPrompt,
-		"chat_history":  chatHistory,
-		"user_query":    userQuery,
-	})
-
+// prepare builds the run context, graph input and call options shared by Generate and Stream,
+// so that local tools find the request state in both paths. A run paused for approval in the
+// same conversation is resumed when the request carries the user's decision.
+func (a *Agent) prepare(ctx context.Context, req *api.ChatRequest, systemPrompt string, chatHistory []*schema.Message, userQuery string, handlers ...callbacks.Handler) (*run, error) {
+	backend, err := a.selectBackend(req.Model)
 	if err != nil {
 		return nil, err
 	}
 
-	return message, nil
+	usage := &Usage{}
+	handlers = append(handlers, usage.callbackHandler())
+	r := &run{
+		ctx:     context.WithValue(ctx, config.StateKey, req),
+		backend: backend,
+		input: map[string]any{
+			"system_prompt": systemPrompt,
+			"chat_history":  chatHistory,
+			"user_query":    userQuery,
+		},
+		usage: usage,
+		opts:  append([]compose.Option{compose.WithCallbacks(handlers...)}, modelOptions(req)...),
+	}
+
+	// 恢复等待确认的运行，需要使用暂停时的模型
+	if pending, resumeOpts := a.resumeOptions(req, userQuery); pending != nil {
+		r.backend = pending.backend
+		r.checkPointID = pending.checkPointID
+		r.opts = append(r.opts, resumeOpts...)
+		return r, nil
+	}
+
+	r.checkPointID = fmt.Sprintf("%s/%d", req.ConversationID, time.Now().UnixNano())
+	r.opts = append(r.opts, compose.WithCheckPointID(r.checkPointID))
+	return r, nil
+}
+
+// Generate generates a single response from the agent along with the token usage of all model calls.
+// When a tool requires confirmation the run pauses and an *ApprovalRequiredError is returned.
+func (a *Agent) Generate(ctx context.Context, req *api.ChatRequest, systemPrompt string, chatHistory []*schema.Message, userQuery string) (*schema.Message, *Usage, error) {
+	// Use the graph of the requested model with branch logic to handle tool calls
+	r, err := a.prepare(ctx, req, systemPrompt, chatHistory, userQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	message, err := r.backend.graph.Invoke(r.ctx, r.input, r.opts...)
+	if err := a.handleRunResult(r.ctx, req, r.backend, r.checkPointID, err); err != nil {
+		return nil, nil, err
+	}
+
+	return message, r.usage, nil
 }
 
-// Stream streams responses from the agent
-func (a *Agent) Stream(ctx context.Context, req *api.ChatRequest, systemPrompt string, chatHistory []*schema.Message, userQuery string) (*schema.StreamReader[*schema.Message], error) {
+// Stream streams responses from the agent; the returned usage is complete once the stream has been consumed.
+// When a tool requires confirmation the run pauses and an *ApprovalRequiredError is returned.
+func (a *Agent) Stream(ctx context.Context, req *api.ChatRequest, systemPrompt string, chatHistory []*schema.Message, userQuery string) (*schema.StreamReader[*schema.Message], *Usage, error) {
 	// For streaming, we use the graph with branch logic
 	printer := nodelog.NewNodelog() // 创建一个中间结果打印器
 	printer.PrintStream()           // 开始异步输出到 console
-	handler := printer.ToCallbackHandler()
-	newCtx := context.WithValue(ctx, config.StateKey, req)
-	return a.einoGraph.Stream(newCtx, map[string]any{
-		"system_prompt": systemPrompt,
-		"chat_history":  chatHistory,
-		"user_query":    userQuery,
-	}, compose.WithCallbacks(handler))
+	r, err := a.prepare(ctx, req, systemPrompt, chatHistory, userQuery, printer.ToCallbackHandler())
+	if err != nil {
+		return nil, nil, err
+	}
+	sr, err := r.backend.graph.Stream(r.ctx, r.input, r.opts...)
+	if err := a.handleRunResult(r.ctx, req, r.backend, r.checkPointID, err); err != nil {
+		return nil, nil, err
+	}
+
+	return sr, r.usage, nil
 }
 
 // Close cleans up resources used by the agent
@@ -102,9 +181,9 @@ func (a *Agent) Close() {
 
 // ToolCall represents a simplified tool call structure for extraction
 type ToolCall struct {
-	ID        string
-	Name      string
-	Arguments string
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
 }
 
 // extractToolCalls extracts tool calls from message, handling different schema versions
@@ -136,8 +215,11 @@ func extractToolCalls(message *schema.Message) []ToolCall {
 		} `json:"tool_calls"`
 	}
 
-	// 尝试将内容解析为JSON对象
-	if err := json.Unmarshal([]byte(message.Content), &response); err == nil {
+	// 去掉推理内容和代码块标记后尝试将内容解析为JSON对象
+	content := strings.TrimSpace(stripThinking(message.Content))
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.Trim(content, "`\n ")
+	if err := json.Unmarshal([]byte(content), &response); err == nil {
 		if len(response.ToolCalls) > 0 {
 			for _, tc := range response.ToolCalls {
 				if tc.Function.Name != "" {
@@ -154,8 +236,8 @@ func extractToolCalls(message *schema.Message) []ToolCall {
 	return toolCalls
 }
 
-// createEinoGraph creates a graph for the chat agent with branch logic for tool handling
-func createEinoGraph(ctx context.Context, mcpManager *mcp.MCPManager, toolManager *tools.ToolManager) (compose.Runnable[map[string]any, *schema.Message], error) {
+// createEinoGraph creates a graph for the chat agent, looping tool results back to the model until it answers
+func createEinoGraph(ctx context.Context, chatModel model.ChatModel, mcpManager *mcp.MCPManager, toolManager *tools.ToolManager, checkPoints compose.CheckPointStore) (compose.Runnable[map[string]any, *schema.Message], error) {
 	// 创建模板
 	template := prompt.FromMessages(schema.FString,
 		schema.SystemMessage("{system_prompt}"),
@@ -163,28 +245,6 @@ func createEinoGraph(ctx context.Context, mcpManager *mcp.MCPManager, toolManage
 		schema.UserMessage("{user_query}"),
 	)
 
-	// 创建聊天模型
-	var chatModel model.ChatModel
-	var err error
-	modelConfig := &openai.ChatModelConfig{
-		Model:  app.Config.OpenAI.ModelID,
-		APIKey: app.Config.OpenAI.APIKey,
-	}
-
-	if app.Config.OpenAI.BaseURL != "" {
-		modelConfig.BaseURL = app.Config.OpenAI.BaseURL
-	}
-
-	if app.Config.OpenAI.MaxTokens > 0 {
-		maxTokens := app.Config.OpenAI.MaxTokens
-		modelConfig.MaxTokens = &maxTokens
-	}
-
-	chatModel, err = openai.NewChatModel(ctx, modelConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create chat model: %w", err)
-	}
-
 	// 组合所有工具
 	allTools := append(toolManager.GetAllTools(), mcpManager.GetAllTools()...)
 
@@ -205,8 +265,7 @@ func createEinoGraph(ctx context.Context, mcpManager *mcp.MCPManager, toolManage
 		}
 
 		if len(toolInfos) > 0 {
-			err = chatModel.BindTools(toolInfos)
-			if err != nil {
+			if err := chatModel.BindTools(toolInfos); err != nil {
 				return nil, fmt.Errorf("failed to bind tools to model: %w", err)
 			}
 		}
@@ -214,215 +273,167 @@ func createEinoGraph(ctx context.Context, mcpManager *mcp.MCPManager, toolManage
 
 	// 定义节点名称常量
 	const (
-		nodePrompt    = "prompt"     // 提示模板节点
-		nodeModel     = "model"      // 模型节点
-		nodeLocalTool = "LocalTool"  // 本地工具节点
-		nodeMcpTool   = "InvorkTool" // MCP调用工具节点
+		nodePrompt = "prompt" // 提示模板节点
+		nodeModel  = "model"  // 模型节点
+		nodeTools  = "tools"  // 工具执行节点（本地工具与MCP工具）
+		// 需要用户确认的工具执行节点，运行到此节点前暂停
+		nodeApproval = "approval"
 	)
 
-	// 创建分支函数，用于判断是否有工具调用以及调用哪个工具
+	// 创建分支函数，读取流式输出直到能确定是否有工具调用
+	lateToolCalls := app.Config.Chat.LateToolCalls
+	executor := newToolExecutor(mcpManager, toolManager, app.Config.Chat.ToolConcurrency)
 	branch := compose.NewStreamGraphBranch(func(ctx context.Context, input *schema.StreamReader[*schema.Message]) (string, error) {
 		log.Printf("branch start Received streaming message")
-		defer input.Close()
-
-		var hasToolCall bool
-		var isLocalTool bool
-		msgCount := 0
-		startTime := time.Now()
-		for {
-			// 添加超时检查
-			if time.Since(startTime) > 3*time.Second {
-				log.Printf("Exceeded 3 second timeout, stopping stream check")
-				break
-			}
-
-			// 添加消息数量检查
-			if msgCount >= 1 {
-				log.Printf("Received message chunk, stopping stream check")
-				break
-			}
-			msgCount++
-
-			msg, err := input.Recv()
-			if err != nil {
-				break
-			}
-
-			toolCalls := extractToolCalls(msg)
-			if len(toolCalls) > 0 {
-				log.Printf("Detected tool calls in stream: %v", toolCalls)
-				hasToolCall = true
 
-				// 检查是否是本地工具
-				for _, tc := range toolCalls {
-					if _, exists := toolManager.GetToolByName(tc.Name); exists {
-						isLocalTool = true
-						break
-					}
-				}
-				break
-			}
+		toolCalls, err := detectToolCalls(input, lateToolCalls, func(index int, delta schema.ToolCall) {
+			emitToolEvent(ctx, ToolEvent{
+				Type:     ToolEventDelta,
+				Index:    index,
+				ToolCall: ToolCall{ID: delta.ID, Name: delta.Function.Name, Arguments: delta.Function.Arguments},
+			})
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to detect tool calls: %w", err)
 		}
 
 		// 根据是否有工具调用决定路由
-		if hasToolCall {
-			log.Printf("Stream complete, routing to tool execution")
-			if isLocalTool {
-				log.Printf("Using LocalTool")
-				return nodeLocalTool, nil
+		if len(toolCalls) == 0 {
+			log.Printf("Stream complete, no tools detected")
+			return compose.END, nil
+		}
+		emitToolEvent(ctx, ToolEvent{Type: ToolEventCallsEnd})
+
+		// 没有 conversation_id 时无法暂停等待确认，由工具节点让需要确认的调用失败
+		if executor.needsApproval(toolCalls) && conversationID(ctx) != "" {
+			// 记录待确认的工具调用，供暂停后生成确认内容
+			err := compose.ProcessState(ctx, func(_ context.Context, state *agentState) error {
+				state.PendingToolCalls = state.PendingToolCalls[:0]
+				for i, tc := range toolCalls {
+					if tc.ID == "" {
+						tc.ID = fmt.Sprintf("call_%d", i)
+					}
+					state.PendingToolCalls = append(state.PendingToolCalls, schema.ToolCall{
+						ID:       tc.ID,
+						Type:     "function",
+						Function: schema.FunctionCall{Name: tc.Name, Arguments: tc.Arguments},
+					})
+				}
+				return nil
+			})
+			if err != nil {
+				return "", fmt.Errorf("failed to record pending tool calls: %w", err)
 			}
-			return nodeMcpTool, nil
+			log.Printf("Stream complete, routing to approval")
+			return nodeApproval, nil
 		}
 
-		log.Printf("Stream complete, no tools detected")
-		return compose.END, nil
-	}, map[string]bool{compose.END: true, nodeMcpTool: true, nodeLocalTool: true})
+		log.Printf("Stream complete, routing to tool execution")
+		return nodeTools, nil
+	}, map[string]bool{compose.END: true, nodeTools: true, nodeApproval: true})
 
-	// 创建本地工具节点
-	localToolNode := compose.InvokableLambda(func(ctx context.Context, input *schema.Message) (*schema.Message, error) {
+	// 创建工具节点，并行执行消息中的所有工具调用，结果以 tool 消息的形式交还给模型
+	toolNode := compose.InvokableLambda(func(ctx context.Context, input *schema.Message) ([]*schema.Message, error) {
 		if input == nil {
 			return nil, fmt.Errorf("no message received")
 		}
-		// 获取原始消息
-		originalMessage := input
-		log.Printf("Processing LocalTool calls in message: %v", originalMessage)
+		log.Printf("Processing tool calls in message: %v", input)
 
 		// 提取工具调用
-		toolCalls := extractToolCalls(originalMessage)
-		if len(toolCalls) == 0 {
-			// 如果没有工具调用，直接返回原始消息
-			return originalMessage, nil
-		}
-
-		// 创建工具调用结果列表
-		toolResultMessages := make([]*schema.Message, 0, len(toolCalls))
-
-		// 处理每个工具调用
-		for _, tc := range toolCalls {
-			// 检查是否是本地工具
-			if _, exists := toolManager.GetToolByName(tc.Name); !exists {
-				continue // 跳过非本地工具
-			}
-
-			log.Printf("Processing LocalTool call: %s with arguments: %s", tc.Name, tc.Arguments)
-
-			// 执行本地工具
-			result, err := toolManager.ExecuteTool(ctx, tc.Name, tc.Arguments)
-
-			// 创建工具调用结果消息
-			toolResultMsg := &schema.Message{
-				Role:       "tool",
-				ToolCallID: tc.ID,
-				Name:       tc.Name,
-			}
-
-			// 处理执行结果或错误
-			if err != nil {
-				log.Printf("Error executing LocalTool %s: %v", tc.Name, err)
-				toolResultMsg.Content = fmt.Sprintf("Error executing tool: %v", err)
-			} else {
-				toolResultMsg.Content = fmt.Sprintf("\n```json\n%s\n```", result)
-			}
-
-			// 添加工具结果消息
-			toolResultMessages = append(toolResultMessages, toolResultMsg)
-		}
-
-		// 通过再次调用模型来获取最终结果
-		finalMsg := &schema.Message{
-			Role:    "assistant",
-			Content: "我已经处理了您的请求，结果如下:\n\n",
-		}
+		toolCalls := extractToolCalls(input)
+		log.Printf("Found %d tool calls to process", len(toolCalls))
 
-		// 添加每个工具的结果
-		for _, toolResult := range toolResultMessages {
-			finalMsg.Content += fmt.Sprintf("**工具**: %s\n**结果**: %s\n\n", toolResult.Name, toolResult.Content)
+		var results []*schema.Message
+		if conversationID(ctx) == "" {
+			results = executor.ExecuteWithoutConversation(ctx, toolCalls)
+		} else {
+			results = executor.ExecuteAll(ctx, toolCalls)
 		}
-
-		return finalMsg, nil
+		recordMessages(ctx, results...)
+		return results, nil
 	})
 
-	// 将格式化工具执行结果的Lambda函数
-	invorkTool := compose.InvokableLambda(func(ctx context.Context, input *schema.Message) (*schema.Message, error) {
+	// 创建确认节点，恢复运行后按用户的决定执行或拒绝需要确认的工具调用
+	approvalNode := compose.InvokableLambda(func(ctx context.Context, input *schema.Message) ([]*schema.Message, error) {
 		if input == nil {
 			return nil, fmt.Errorf("no message received")
 		}
 
-		// 获取原始消息
-		originalMessage := input
-		log.Printf("Processing tool calls in message: %v", originalMessage)
-
-		// 提取工具调用
-		toolCalls := extractToolCalls(originalMessage)
-		if len(toolCalls) == 0 {
-			// 如果没有工具调用，直接返回原始消息
-			return originalMessage, nil
+		var decision string
+		err := compose.ProcessState(ctx, func(_ context.Context, state *agentState) error {
+			decision = state.Approval
+			state.Approval = ""
+			state.PendingToolCalls = nil
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read approval decision: %w", err)
 		}
+		log.Printf("Processing tool calls with approval decision %q", decision)
 
-		log.Printf("Found %d tool calls to process", len(toolCalls))
-
-		// 创建工具调用结果列表
-		content := "没处理结果"
-		toolName := ""
-		for _, tc := range toolCalls {
-			// 跳过本地工具调用，因为它有自己的处理节点
-			if _, exists := toolManager.GetToolByName(tc.Name); exists {
-				continue
-			}
+		results := executor.ExecuteApproved(ctx, extractToolCalls(input), decision)
+		recordMessages(ctx, results...)
+		return results, nil
+	})
 
-			log.Printf("Processing MCP tool call: %s with arguments: %s", tc.Name, tc.Arguments)
+	// 模型节点前置处理：累积对话消息，让模型看到之前的工具调用及结果
+	modelPreHandler := func(ctx context.Context, input []*schema.Message, state *agentState) ([]*schema.Message, error) {
+		state.Messages = append(state.Messages, input...)
+		return state.Messages, nil
+	}
 
-			// 调用工具并获取结果
-			result, err := executeMCPTool(ctx, mcpManager, tc.Name, tc.Arguments)
-			toolName = tc.Name
-			// 处理执行结果或错误
-			if err != nil {
-				log.Printf("Error executing tool %s: %v", tc.Name, err)
-				content = fmt.Sprintf("Error executing tool: %v", err)
-			} else {
-				callToolResult := &models.MCPResult{}
-				err = json.Unmarshal([]byte(result), &callToolResult)
-				if err != nil {
-					log.Printf("Error unmarshalling tool result: %v", err)
-					content = result
-				} else {
-					content = fmt.Sprintf("%v", callToolResult.Content[0].Text)
+	// 工具节点前置处理：合并流式分片中的工具调用，并记录包含工具调用的助手消息
+	toolPreHandler := func(ctx context.Context, input *schema.Message, state *agentState) (*schema.Message, error) {
+		if len(input.ToolCalls) > 0 {
+			input.ToolCalls = mergeToolCalls(input.ToolCalls)
+		} else {
+			// 工具调用写在正文中时转换为标准的工具调用，保证后续 tool 消息能与之对应
+			for i, tc := range extractToolCalls(input) {
+				if tc.ID == "" {
+					tc.ID = fmt.Sprintf("call_%d", i)
 				}
+				input.ToolCalls = append(input.ToolCalls, schema.ToolCall{
+					ID:       tc.ID,
+					Type:     "function",
+					Function: schema.FunctionCall{Name: tc.Name, Arguments: tc.Arguments},
+				})
+			}
+			if len(input.ToolCalls) > 0 {
+				input.Content = ""
 			}
-			break
-		}
-
-		// 通过再次调用模型来获取最终结果
-		// 这里应该使用一个新的模型调用，但为了简化流程，我们将返回一个特殊的消息
-		// 在真实系统中，应该重新调用模型或使用另一个节点来处理
-		finalMsg := &schema.Message{
-			Role:    "assistant",
-			Content: "我已经处理了您的工具1调用请求，结果如下:\n\n",
 		}
-
-		// 添加每个工具的结果
-		finalMsg.Content += fmt.Sprintf("**工具**: %s\n**结果**: %s\n\n", toolName, content)
-		return finalMsg, nil
-	})
+		state.Messages = append(state.Messages, input)
+		recordMessages(ctx, input)
+		return input, nil
+	}
 
 	// 创建图实例
-	g := compose.NewGraph[map[string]any, *schema.Message]()
+	g := compose.NewGraph[map[string]any, *schema.Message](compose.WithGenLocalState(func(ctx context.Context) *agentState {
+		return &agentState{}
+	}))
 	// 添加节点
 	_ = g.AddChatTemplateNode(nodePrompt, template, compose.WithNodeName("ChatTemplate"))
-	_ = g.AddChatModelNode(nodeModel, chatModel, compose.WithNodeName("LLMModel"))
-	// 添加本地工具节点
-	_ = g.AddLambdaNode(nodeLocalTool, localToolNode, compose.WithNodeName("LocalToolExecutor"))
-	// 添加MCP工具节点
-	_ = g.AddLambdaNode(nodeMcpTool, invorkTool, compose.WithNodeName("McpToolExecutor"))
+	_ = g.AddChatModelNode(nodeModel, chatModel, compose.WithNodeName("LLMModel"), compose.WithStatePreHandler(modelPreHandler))
+	// 添加工具节点
+	_ = g.AddLambdaNode(nodeTools, toolNode, compose.WithNodeName("ToolExecutor"), compose.WithStatePreHandler(toolPreHandler))
+	_ = g.AddLambdaNode(nodeApproval, approvalNode, compose.WithNodeName("ApprovalToolExecutor"), compose.WithStatePreHandler(toolPreHandler))
 	// 连接节点
 	_ = g.AddEdge(compose.START, nodePrompt)
 	_ = g.AddEdge(nodePrompt, nodeModel)
-	_ = g.AddBranch(nodeModel, branch)        // 添加分支，决定是否需要调用工具
-	_ = g.AddEdge(nodeLocalTool, compose.END) // 本地工具结果返回
-	_ = g.AddEdge(nodeMcpTool, compose.END)   // MCP工具结果返回
+	_ = g.AddBranch(nodeModel, branch)  // 添加分支，决定是否需要调用工具
+	_ = g.AddEdge(nodeTools, nodeModel) // 工具结果交还给模型
+	_ = g.AddEdge(nodeApproval, nodeModel)
 
 	// 编译图
-	r, err := g.Compile(ctx)
+	maxSteps := app.Config.Chat.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = defaultMaxSteps
+	}
+	r, err := g.Compile(ctx,
+		compose.WithMaxRunSteps(maxSteps),
+		compose.WithCheckPointStore(checkPoints),
+		compose.WithInterruptBeforeNodes([]string{nodeApproval}), // 需要确认的工具执行前暂停
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compile graph: %w", err)
 	}
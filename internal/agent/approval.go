@@ -0,0 +1,229 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+
+	"coder/api"
+	"coder/internal/cache"
+	"coder/internal/config"
+	"coder/internal/envelope"
+)
+
+// Approval decisions sent by the client to resume a paused run
+const (
+	ApprovalApprove = "approve"
+	ApprovalReject  = "reject"
+)
+
+func init() {
+	// 检查点中保存图状态，需要注册为可序列化类型
+	if err := compose.RegisterSerializableType[agentState]("coder_agent_state"); err != nil {
+		log.Printf("Failed to register agent state type: %v", err)
+	}
+}
+
+// PendingToolCall is a tool call waiting for the user's approval together with the changes it would make
+type PendingToolCall struct {
+	ToolCall
+	Preview interface{} `json:"preview,omitempty"`
+}
+
+// PendingApproval describes a paused run waiting for the user to approve or reject its tool calls
+type PendingApproval struct {
+	ConversationID string            `json:"conversation_id"`
+	ToolCalls      []PendingToolCall `json:"tool_calls"`
+	CreatedAt      time.Time         `json:"created_at"`
+
+	checkPointID string
+	backend      *modelBackend
+}
+
+// ApprovalRequiredError is returned by Generate and Stream when the run paused before a tool that requires confirmation
+type ApprovalRequiredError struct {
+	Pending *PendingApproval
+}
+
+func (e *ApprovalRequiredError) Error() string {
+	return fmt.Sprintf("approval required for %d tool calls in conversation %s", len(e.Pending.ToolCalls), e.Pending.ConversationID)
+}
+
+// checkPointStore keeps graph checkpoints of paused runs in memory
+type checkPointStore struct {
+	cache *cache.Cache[[]byte]
+}
+
+// newCheckPointStore creates an in-memory checkpoint store
+func newCheckPointStore() *checkPointStore {
+	return &checkPointStore{cache: cache.New[[]byte]()}
+}
+
+// Get returns the checkpoint saved under the given id
+func (s *checkPointStore) Get(_ context.Context, checkPointID string) ([]byte, bool, error) {
+	data, ok := s.cache.Get(checkPointID)
+	return data, ok, nil
+}
+
+// Set saves a checkpoint under the given id
+func (s *checkPointStore) Set(_ context.Context, checkPointID string, checkPoint []byte) error {
+	s.cache.Set(checkPointID, checkPoint, cache.DefaultCacheExpiration)
+	return nil
+}
+
+// Delete removes the checkpoint saved under the given id
+func (s *checkPointStore) Delete(checkPointID string) {
+	s.cache.Delete(checkPointID)
+}
+
+// parseApprovalDecision reads an approve or reject decision from the request or the user's message
+func parseApprovalDecision(req *api.ChatRequest, userQuery string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(req.Approval)) {
+	case ApprovalApprove:
+		return ApprovalApprove, true
+	case ApprovalReject:
+		return ApprovalReject, true
+	}
+
+	// 兼容直接回复文字确认或拒绝
+	text := strings.ToLower(strings.Trim(strings.TrimSpace(userQuery), "。.!！"))
+	switch text {
+	case "approve", "approved", "yes", "y", "ok", "确认", "确定", "同意", "保存", "是":
+		return ApprovalApprove, true
+	case "reject", "rejected", "no", "n", "拒绝", "取消", "不同意", "不保存", "否":
+		return ApprovalReject, true
+	}
+	return "", false
+}
+
+// resumeOptions returns the options that resume a paused run of the conversation, if any.
+// A pending approval that is answered with anything but a decision is dropped and a new run starts.
+func (a *Agent) resumeOptions(req *api.ChatRequest, userQuery string) (*PendingApproval, []compose.Option) {
+	// 没有 conversation_id 的请求之间无法区分，不能恢复任何运行
+	if req.ConversationID == "" {
+		return nil, nil
+	}
+	pending, ok := a.approvals.Get(req.ConversationID)
+	if !ok {
+		return nil, nil
+	}
+	a.approvals.Delete(req.ConversationID)
+
+	decision, ok := parseApprovalDecision(req, userQuery)
+	if !ok {
+		log.Printf("Discarding pending approval of conversation %s, no decision in message", req.ConversationID)
+		a.checkPoints.Delete(pending.checkPointID)
+		return nil, nil
+	}
+
+	log.Printf("Resuming conversation %s with decision %s", req.ConversationID, decision)
+	return pending, []compose.Option{
+		compose.WithCheckPointID(pending.checkPointID),
+		compose.WithStateModifier(func(ctx context.Context, path compose.NodePath, state any) error {
+			if s, ok := state.(*agentState); ok {
+				s.Approval = decision
+			}
+			return nil
+		}),
+	}
+}
+
+// handleRunResult records a pending approval when the run was interrupted and cleans up the checkpoint otherwise
+func (a *Agent) handleRunResult(ctx context.Context, req *api.ChatRequest, backend *modelBackend, checkPointID string, err error) error {
+	info, interrupted := compose.ExtractInterruptInfo(err)
+	if !interrupted {
+		a.checkPoints.Delete(checkPointID)
+		return err
+	}
+	if req.ConversationID == "" {
+		// 没有 conversation_id 的运行不会路由到确认节点，这里只作兜底
+		a.checkPoints.Delete(checkPointID)
+		return envelope.Errorf(envelope.CodeRejected, "approval requires a conversation_id")
+	}
+
+	pending := &PendingApproval{
+		ConversationID: req.ConversationID,
+		CreatedAt:      time.Now(),
+		checkPointID:   checkPointID,
+		backend:        backend,
+	}
+	if state, ok := info.State.(*agentState); ok {
+		for _, tc := range extractToolCalls(&schema.Message{ToolCalls: state.PendingToolCalls}) {
+			call := PendingToolCall{ToolCall: tc}
+			if a.toolManager.RequiresConfirmation(tc.Name) {
+				preview, err := a.toolManager.PreviewTool(ctx, tc.Name, tc.Arguments)
+				if err != nil {
+					log.Printf("Failed to preview tool %s: %v", tc.Name, err)
+					preview = map[string]interface{}{"error": err.Error()}
+				}
+				call.Preview = preview
+			}
+			pending.ToolCalls = append(pending.ToolCalls, call)
+		}
+	}
+
+	a.approvals.Set(req.ConversationID, pending, cache.DefaultCacheExpiration)
+	return &ApprovalRequiredError{Pending: pending}
+}
+
+// needsApproval reports whether any of the tool calls requires the user's confirmation
+func (e *toolExecutor) needsApproval(toolCalls []ToolCall) bool {
+	for _, tc := range toolCalls {
+		if e.toolManager.RequiresConfirmation(tc.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExecuteApproved runs the tool calls of a message that was paused for approval; when the user
+// rejected it, calls that require confirmation are answered with a rejection instead of running
+func (e *toolExecutor) ExecuteApproved(ctx context.Context, toolCalls []ToolCall, decision string) []*schema.Message {
+	if decision == ApprovalApprove {
+		return e.ExecuteAll(ctx, toolCalls)
+	}
+	return e.executeUnconfirmed(ctx, toolCalls, func(tc ToolCall) error {
+		return envelope.Errorf(envelope.CodeRejected, "user rejected the call to %s, nothing was saved", tc.Name)
+	})
+}
+
+// ExecuteWithoutConversation runs the tool calls of a request without a conversation_id. Such a run cannot pause
+// for approval, since any other anonymous request could answer it, so calls that require confirmation fail.
+func (e *toolExecutor) ExecuteWithoutConversation(ctx context.Context, toolCalls []ToolCall) []*schema.Message {
+	return e.executeUnconfirmed(ctx, toolCalls, func(tc ToolCall) error {
+		return envelope.Errorf(envelope.CodeRejected, "%s requires the user's approval, which needs a conversation_id; nothing was saved", tc.Name)
+	})
+}
+
+// executeUnconfirmed runs the tool calls that do not require confirmation and answers the others with refuse
+func (e *toolExecutor) executeUnconfirmed(ctx context.Context, toolCalls []ToolCall, refuse func(tc ToolCall) error) []*schema.Message {
+	results := make([]*schema.Message, len(toolCalls))
+	var allowed []ToolCall
+	var allowedIndexes []int
+	for i, tc := range toolCalls {
+		if e.toolManager.RequiresConfirmation(tc.Name) {
+			results[i] = schema.ToolMessage(envelope.Fail(refuse(tc)), tc.ID)
+			continue
+		}
+		allowed = append(allowed, tc)
+		allowedIndexes = append(allowedIndexes, i)
+	}
+
+	for i, msg := range e.ExecuteAll(ctx, allowed) {
+		results[allowedIndexes[i]] = msg
+	}
+	return results
+}
+
+// conversationID returns the conversation of the request in ctx, empty when the request has none
+func conversationID(ctx context.Context) string {
+	if req, ok := ctx.Value(config.StateKey).(*api.ChatRequest); ok {
+		return req.ConversationID
+	}
+	return ""
+}
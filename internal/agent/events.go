@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"context"
+	"time"
+)
+
+// 工具事件类型
+const (
+	ToolEventDelta    = "tool_call_delta" // 模型流式输出的工具调用分片
+	ToolEventCallsEnd = "tool_calls_end"  // 模型本轮输出以工具调用结束
+	ToolEventStart    = "tool_start"      // 工具开始执行
+	ToolEventResult   = "tool_result"     // 工具返回结果
+	ToolEventEnd      = "tool_end"        // 工具执行结束
+)
+
+// ToolEvent describes the progress of a single tool call during a run, from the deltas streamed by the model
+// to the end of its execution
+type ToolEvent struct {
+	Type      string   `json:"type"`
+	Index     int      `json:"index,omitempty"` // Position of the tool call in the model turn, set for deltas
+	ToolCall  ToolCall `json:"tool_call"`
+	Result    string   `json:"result,omitempty"`
+	Duration  int64    `json:"duration_ms,omitempty"`
+	Timestamp int64    `json:"timestamp"`
+}
+
+// ToolEventHandler receives the tool events of a run; it may be called from several goroutines
+type ToolEventHandler func(event ToolEvent)
+
+type toolEventKey struct{}
+
+// WithToolEventHandler returns a context that delivers the tool events of a run to handler
+func WithToolEventHandler(ctx context.Context, handler ToolEventHandler) context.Context {
+	return context.WithValue(ctx, toolEventKey{}, handler)
+}
+
+// emitToolEvent sends a tool event to the handler registered in the context, if any
+func emitToolEvent(ctx context.Context, event ToolEvent) {
+	handler, ok := ctx.Value(toolEventKey{}).(ToolEventHandler)
+	if !ok || handler == nil {
+		return
+	}
+	event.Timestamp = time.Now().UnixMilli()
+	handler(event)
+}
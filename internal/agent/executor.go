@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"coder/internal/envelope"
+	"coder/internal/mcp"
+	"coder/internal/models"
+	"coder/internal/tools"
+)
+
+// defaultToolConcurrency is the number of tool calls run at once when chat.tool_concurrency is not configured
+const defaultToolConcurrency = 4
+
+// toolExecutor runs the tool calls of an assistant message against local and MCP tools
+type toolExecutor struct {
+	mcpManager  *mcp.MCPManager
+	toolManager *tools.ToolManager
+	concurrency int
+}
+
+// newToolExecutor creates a tool executor with the given concurrency limit
+func newToolExecutor(mcpManager *mcp.MCPManager, toolManager *tools.ToolManager, concurrency int) *toolExecutor {
+	if concurrency <= 0 {
+		concurrency = defaultToolConcurrency
+	}
+	return &toolExecutor{
+		mcpManager:  mcpManager,
+		toolManager: toolManager,
+		concurrency: concurrency,
+	}
+}
+
+// ExecuteAll runs every tool call and returns the tool messages in the same order as the calls.
+// MCP calls run in parallel; local calls share the conversation's draft, so they run one after
+// another in call order alongside the MCP calls.
+func (e *toolExecutor) ExecuteAll(ctx context.Context, toolCalls []ToolCall) []*schema.Message {
+	results := make([]*schema.Message, len(toolCalls))
+	sem := make(chan struct{}, e.concurrency)
+	var wg sync.WaitGroup
+
+	// run 在并发限制内执行一组工具调用
+	run := func(indexes []int) {
+		defer wg.Done()
+
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+		case <-ctx.Done():
+			for _, i := range indexes {
+				results[i] = schema.ToolMessage(envelope.Fail(ctx.Err()), toolCalls[i].ID)
+			}
+			return
+		}
+
+		for _, i := range indexes {
+			tc := toolCalls[i]
+			emitToolEvent(ctx, ToolEvent{Type: ToolEventStart, ToolCall: tc})
+			start := time.Now()
+
+			result := e.execute(ctx, tc)
+			results[i] = schema.ToolMessage(result, tc.ID)
+
+			emitToolEvent(ctx, ToolEvent{Type: ToolEventResult, ToolCall: tc, Result: result})
+			emitToolEvent(ctx, ToolEvent{Type: ToolEventEnd, ToolCall: tc, Duration: time.Since(start).Milliseconds()})
+		}
+	}
+
+	var localIndexes []int
+	for i, tc := range toolCalls {
+		if _, exists := e.toolManager.GetToolByName(tc.Name); exists {
+			localIndexes = append(localIndexes, i)
+			continue
+		}
+		wg.Add(1)
+		go run([]int{i})
+	}
+	if len(localIndexes) > 0 {
+		wg.Add(1)
+		go run(localIndexes)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// execute runs a single tool call, dispatching to the local tool manager or the MCP servers
+func (e *toolExecutor) execute(ctx context.Context, tc ToolCall) string {
+	// 本地工具优先
+	if _, exists := e.toolManager.GetToolByName(tc.Name); exists {
+		log.Printf("Processing LocalTool call: %s with arguments: %s", tc.Name, tc.Arguments)
+
+		result, err := e.toolManager.ExecuteTool(ctx, tc.Name, tc.Arguments)
+		if err != nil {
+			log.Printf("Error executing LocalTool %s: %v", tc.Name, err)
+			return envelope.Fail(err)
+		}
+		return result
+	}
+
+	log.Printf("Processing MCP tool call: %s with arguments: %s", tc.Name, tc.Arguments)
+
+	ctx, cancel := context.WithTimeout(ctx, tools.Timeout(tc.Name))
+	defer cancel()
+	result, err := executeMCPTool(ctx, e.mcpManager, tc.Name, tc.Arguments)
+	if err != nil {
+		log.Printf("Error executing tool %s: %v", tc.Name, err)
+		return envelope.Fail(err)
+	}
+
+	callToolResult := &models.MCPResult{}
+	if err := json.Unmarshal([]byte(result), &callToolResult); err != nil {
+		log.Printf("Error unmarshalling tool result: %v", err)
+		return envelope.Normalize(result)
+	}
+	if len(callToolResult.Content) > 0 {
+		return envelope.Normalize(callToolResult.Content[0].Text)
+	}
+	return envelope.Normalize(result)
+}
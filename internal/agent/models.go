@@ -0,0 +1,131 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino-ext/components/model/deepseek"
+	"github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+
+	"coder/api"
+	"coder/app"
+	"coder/config"
+)
+
+// 支持的模型提供方
+const (
+	providerOpenAI   = "openai"
+	providerDeepSeek = "deepseek"
+)
+
+// modelBackend is a named chat model together with the graph compiled for it
+type modelBackend struct {
+	config config.ModelConfig
+	graph  compose.Runnable[map[string]any, *schema.Message]
+}
+
+// modelConfigs returns the configured model backends, the [openai] section first as the default
+func modelConfigs() []config.ModelConfig {
+	defaultModel := config.ModelConfig{
+		Name:      app.Config.OpenAI.ModelID,
+		Provider:  providerOpenAI,
+		APIKey:    app.Config.OpenAI.APIKey,
+		ModelID:   app.Config.OpenAI.ModelID,
+		BaseURL:   app.Config.OpenAI.BaseURL,
+		MaxTokens: app.Config.OpenAI.MaxTokens,
+	}
+
+	configs := []config.ModelConfig{defaultModel}
+	for _, m := range app.Config.Models {
+		if m.Name == "" {
+			m.Name = m.ModelID
+		}
+		if m.Provider == "" {
+			m.Provider = providerOpenAI
+		}
+		configs = append(configs, m)
+	}
+	return configs
+}
+
+// newChatModel creates a chat model for the given backend configuration
+func newChatModel(ctx context.Context, cfg config.ModelConfig) (model.ChatModel, error) {
+	switch cfg.Provider {
+	case providerOpenAI:
+		modelConfig := &openai.ChatModelConfig{
+			Model:   cfg.ModelID,
+			APIKey:  cfg.APIKey,
+			BaseURL: cfg.BaseURL,
+		}
+		if cfg.MaxTokens > 0 {
+			maxTokens := cfg.MaxTokens
+			modelConfig.MaxTokens = &maxTokens
+		}
+		return openai.NewChatModel(ctx, modelConfig)
+	case providerDeepSeek:
+		return deepseek.NewChatModel(ctx, &deepseek.ChatModelConfig{
+			Model:     cfg.ModelID,
+			APIKey:    cfg.APIKey,
+			BaseURL:   cfg.BaseURL,
+			MaxTokens: cfg.MaxTokens,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported model provider '%s' for model '%s'", cfg.Provider, cfg.Name)
+	}
+}
+
+// ErrUnknownModel is returned when a request names a model that is not configured
+var ErrUnknownModel = errors.New("unknown model")
+
+// selectBackend picks the backend requested by name or model id, or the default backend when no model is requested
+func (a *Agent) selectBackend(name string) (*modelBackend, error) {
+	if name == "" {
+		return a.backends[0], nil
+	}
+	for _, b := range a.backends {
+		if b.config.Name == name {
+			return b, nil
+		}
+	}
+	for _, b := range a.backends {
+		if b.config.ModelID == name {
+			return b, nil
+		}
+	}
+
+	known := make([]string, 0, len(a.backends))
+	for _, b := range a.backends {
+		known = append(known, b.config.Name)
+	}
+	return nil, fmt.Errorf("%w '%s', must be one of: %s", ErrUnknownModel, name, strings.Join(known, ", "))
+}
+
+// ResolveModel returns the model id that serves a request for the given model name
+func (a *Agent) ResolveModel(name string) (string, error) {
+	b, err := a.selectBackend(name)
+	if err != nil {
+		return "", err
+	}
+	return b.config.ModelID, nil
+}
+
+// modelOptions converts the per-request model settings into Eino call options
+func modelOptions(req *api.ChatRequest) []compose.Option {
+	var opts []model.Option
+	// 未设置的参数不传，由各模型的配置决定
+	if req.Temperature != nil {
+		opts = append(opts, model.WithTemperature(*req.Temperature))
+	}
+	if req.MaxTokens > 0 {
+		opts = append(opts, model.WithMaxTokens(req.MaxTokens))
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+	return []compose.Option{compose.WithChatModelOption(opts...)}
+}
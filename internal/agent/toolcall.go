@@ -0,0 +1,185 @@
+package agent
+
+import (
+	"errors"
+	"io"
+	"log"
+	"maps"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// detectToolCalls reads a streamed model output until it is certain whether the message carries tool calls
+// and returns the tool calls found. Leading empty chunks and reasoning blocks are skipped, text that may be
+// a JSON encoded tool call is accumulated until the end of the stream, and when lateToolCalls is set the
+// whole stream is read so that tool calls emitted after plain text are still detected. Once a tool call
+// delta is seen the rest of the stream is read so that every call of the message is returned.
+// onDelta, when not nil, receives every tool call delta as it is read together with the position of its
+// tool call in the message; tool calls written in the content are passed whole once the stream ends.
+func detectToolCalls(sr *schema.StreamReader[*schema.Message], lateToolCalls bool, onDelta func(index int, delta schema.ToolCall)) ([]ToolCall, error) {
+	defer sr.Close()
+
+	var content strings.Builder
+	var merger toolCallMerger
+	for {
+		msg, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// 出现工具调用分片后读取完整消息，收集全部工具调用
+		if len(msg.ToolCalls) > 0 || len(merger.merged) > 0 {
+			for _, delta := range msg.ToolCalls {
+				index := merger.add(delta)
+				// 边读取边转发分片，客户端无需等待模型输出结束
+				if onDelta != nil {
+					onDelta(index, delta)
+				}
+			}
+			continue
+		}
+
+		content.WriteString(msg.Content)
+		text := strings.TrimSpace(stripThinking(content.String()))
+		if text == "" {
+			// 仍是空内容或推理内容，继续读取
+			continue
+		}
+		if looksLikeToolCallText(text) {
+			// 可能是写在正文里的工具调用，需要读取完整内容后再判断
+			continue
+		}
+		if !lateToolCalls {
+			return nil, nil
+		}
+	}
+
+	if len(merger.merged) > 0 {
+		return extractToolCalls(&schema.Message{ToolCalls: merger.toolCalls()}), nil
+	}
+
+	toolCalls := extractToolCalls(&schema.Message{Content: strings.TrimSpace(stripThinking(content.String()))})
+	if len(toolCalls) > 0 {
+		log.Printf("Detected %d tool calls in message content", len(toolCalls))
+	}
+	if onDelta != nil {
+		for i, tc := range toolCalls {
+			onDelta(i, schema.ToolCall{ID: tc.ID, Type: "function", Function: schema.FunctionCall{Name: tc.Name, Arguments: tc.Arguments}})
+		}
+	}
+	return toolCalls, nil
+}
+
+// stripThinking removes a leading <think>...</think> block; an unclosed block yields an empty string
+func stripThinking(content string) string {
+	trimmed := strings.TrimLeft(content, " \t\r\n")
+	if !strings.HasPrefix(trimmed, "<think>") {
+		return content
+	}
+	end := strings.Index(trimmed, "</think>")
+	if end < 0 {
+		return ""
+	}
+	return trimmed[end+len("</think>"):]
+}
+
+// looksLikeToolCallText reports whether the text may be the start of a tool call written as JSON content
+func looksLikeToolCallText(text string) bool {
+	return strings.HasPrefix(text, "{") || strings.HasPrefix(text, "```")
+}
+
+// mergeToolCalls merges streamed tool call deltas into complete tool calls.
+// Deltas that carry an Index are merged with earlier deltas of the same index. A delta without an
+// Index starts a new tool call when it carries an ID other than the most recent call's, or a function
+// name while the most recent call already has one; otherwise it is appended to the most recent call.
+// The order of first appearance is kept.
+func mergeToolCalls(deltas []schema.ToolCall) []schema.ToolCall {
+	var m toolCallMerger
+	for _, delta := range deltas {
+		m.add(delta)
+	}
+	return m.toolCalls()
+}
+
+// toolCallMerger merges tool call deltas one at a time, see mergeToolCalls
+type toolCallMerger struct {
+	merged  []schema.ToolCall
+	byIndex map[int]int
+}
+
+// add merges a delta and returns the position of the tool call it belongs to
+func (m *toolCallMerger) add(delta schema.ToolCall) int {
+	if m.byIndex == nil {
+		m.byIndex = make(map[int]int)
+	}
+
+	pos := -1
+	if delta.Index != nil {
+		if p, ok := m.byIndex[*delta.Index]; ok {
+			pos = p
+		}
+	} else if len(m.merged) > 0 && continuesCall(m.merged[len(m.merged)-1], delta) {
+		pos = len(m.merged) - 1
+	}
+
+	if pos < 0 {
+		tc := delta
+		tc.Extra = maps.Clone(delta.Extra)
+		if delta.Index != nil {
+			index := *delta.Index
+			tc.Index = &index
+			m.byIndex[index] = len(m.merged)
+		}
+		m.merged = append(m.merged, tc)
+		return len(m.merged) - 1
+	}
+
+	tc := &m.merged[pos]
+	if tc.ID == "" {
+		tc.ID = delta.ID
+	}
+	if tc.Type == "" {
+		tc.Type = delta.Type
+	}
+	if tc.Function.Name == "" {
+		tc.Function.Name = delta.Function.Name
+	}
+	tc.Function.Arguments += delta.Function.Arguments
+	if len(delta.Extra) > 0 {
+		if tc.Extra == nil {
+			tc.Extra = make(map[string]any, len(delta.Extra))
+		}
+		for k, v := range delta.Extra {
+			tc.Extra[k] = v
+		}
+	}
+	return pos
+}
+
+// toolCalls returns the merged tool calls, with empty arguments replaced by an empty object
+func (m *toolCallMerger) toolCalls() []schema.ToolCall {
+	merged := append(make([]schema.ToolCall, 0, len(m.merged)), m.merged...)
+	for i := range merged {
+		if strings.TrimSpace(merged[i].Function.Arguments) == "" {
+			merged[i].Function.Arguments = "{}"
+		}
+	}
+	return merged
+}
+
+// continuesCall reports whether a delta without an Index belongs to the tool call last. Two IDs decide on their
+// own; without them a function name starts a new call unless last has no name yet.
+func continuesCall(last, delta schema.ToolCall) bool {
+	switch {
+	case delta.ID != "" && last.ID != "":
+		return delta.ID == last.ID
+	case delta.Function.Name != "":
+		return last.Function.Name == ""
+	default:
+		return true
+	}
+}
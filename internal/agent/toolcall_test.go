@@ -0,0 +1,216 @@
+package agent
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func toolCallDelta(idx *int, id, name, arguments string) schema.ToolCall {
+	return schema.ToolCall{Index: idx, ID: id, Function: schema.FunctionCall{Name: name, Arguments: arguments}}
+}
+
+func TestMergeToolCalls(t *testing.T) {
+	tests := []struct {
+		name   string
+		deltas []schema.ToolCall
+		want   []ToolCall
+	}{
+		{
+			name: "index keyed deltas",
+			deltas: []schema.ToolCall{
+				toolCallDelta(intPtr(0), "call_a", "addField", `{"range_code":`),
+				toolCallDelta(intPtr(1), "call_b", "addApi", `{"type":`),
+				toolCallDelta(intPtr(0), "", "", `"create"}`),
+				toolCallDelta(intPtr(1), "", "", `"listAPI"}`),
+			},
+			want: []ToolCall{
+				{ID: "call_a", Name: "addField", Arguments: `{"range_code":"create"}`},
+				{ID: "call_b", Name: "addApi", Arguments: `{"type":"listAPI"}`},
+			},
+		},
+		{
+			name: "no index with ids",
+			deltas: []schema.ToolCall{
+				toolCallDelta(nil, "call_a", "addField", `{"a":`),
+				toolCallDelta(nil, "call_a", "", `1}`),
+				toolCallDelta(nil, "call_b", "deleteField", `{}`),
+			},
+			want: []ToolCall{
+				{ID: "call_a", Name: "addField", Arguments: `{"a":1}`},
+				{ID: "call_b", Name: "deleteField", Arguments: `{}`},
+			},
+		},
+		{
+			name: "no index without ids",
+			deltas: []schema.ToolCall{
+				toolCallDelta(nil, "", "addField", `{"a":`),
+				toolCallDelta(nil, "", "", `1}`),
+				toolCallDelta(nil, "", "deleteField", `{"b":2}`),
+			},
+			want: []ToolCall{
+				{Name: "addField", Arguments: `{"a":1}`},
+				{Name: "deleteField", Arguments: `{"b":2}`},
+			},
+		},
+		{
+			name: "no index id before name",
+			deltas: []schema.ToolCall{
+				toolCallDelta(nil, "call_a", "", ``),
+				toolCallDelta(nil, "", "addField", `{"a":1}`),
+			},
+			want: []ToolCall{
+				{ID: "call_a", Name: "addField", Arguments: `{"a":1}`},
+			},
+		},
+		{
+			name: "two calls to the same tool without ids",
+			deltas: []schema.ToolCall{
+				toolCallDelta(nil, "", "addField", ``),
+				toolCallDelta(nil, "", "addField", `{"b":2}`),
+			},
+			want: []ToolCall{
+				{Name: "addField", Arguments: `{}`},
+				{Name: "addField", Arguments: `{"b":2}`},
+			},
+		},
+		{
+			name: "two calls to the same tool with ids",
+			deltas: []schema.ToolCall{
+				toolCallDelta(nil, "call_a", "addField", `{"a":1}`),
+				toolCallDelta(nil, "call_b", "addField", `{"b":`),
+				toolCallDelta(nil, "", "", `2}`),
+			},
+			want: []ToolCall{
+				{ID: "call_a", Name: "addField", Arguments: `{"a":1}`},
+				{ID: "call_b", Name: "addField", Arguments: `{"b":2}`},
+			},
+		},
+		{
+			name: "two calls to the same tool by index",
+			deltas: []schema.ToolCall{
+				toolCallDelta(intPtr(0), "call_a", "addField", `{"a":1}`),
+				toolCallDelta(intPtr(1), "call_b", "addField", `{"b":2}`),
+			},
+			want: []ToolCall{
+				{ID: "call_a", Name: "addField", Arguments: `{"a":1}`},
+				{ID: "call_b", Name: "addField", Arguments: `{"b":2}`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractToolCalls(&schema.Message{ToolCalls: mergeToolCalls(tt.deltas)})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeToolCalls() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectToolCalls(t *testing.T) {
+	tests := []struct {
+		name          string
+		chunks        []*schema.Message
+		lateToolCalls bool
+		want          []ToolCall
+	}{
+		{
+			name:   "plain text",
+			chunks: []*schema.Message{{Content: "你好"}, {Content: "，有什么可以帮你"}},
+			want:   nil,
+		},
+		{
+			name: "streamed tool call deltas",
+			chunks: []*schema.Message{
+				{Content: ""},
+				{ToolCalls: []schema.ToolCall{toolCallDelta(intPtr(0), "call_a", "viewModule", `{"module_code":`)}},
+				{ToolCalls: []schema.ToolCall{toolCallDelta(intPtr(0), "", "", `"user"}`)}},
+				{ToolCalls: []schema.ToolCall{toolCallDelta(intPtr(1), "call_b", "viewModule", `{"module_code":"role"}`)}},
+			},
+			want: []ToolCall{
+				{ID: "call_a", Name: "viewModule", Arguments: `{"module_code":"user"}`},
+				{ID: "call_b", Name: "viewModule", Arguments: `{"module_code":"role"}`},
+			},
+		},
+		{
+			name: "text followed by a late tool call",
+			chunks: []*schema.Message{
+				{Content: "我来查看模块。"},
+				{ToolCalls: []schema.ToolCall{toolCallDelta(intPtr(0), "call_a", "viewModule", `{}`)}},
+			},
+			lateToolCalls: true,
+			want:          []ToolCall{{ID: "call_a", Name: "viewModule", Arguments: `{}`}},
+		},
+		{
+			name: "text followed by a late tool call when not waiting for one",
+			chunks: []*schema.Message{
+				{Content: "我来查看模块。"},
+				{ToolCalls: []schema.ToolCall{toolCallDelta(intPtr(0), "call_a", "viewModule", `{}`)}},
+			},
+			want: nil,
+		},
+		{
+			name: "think prefix",
+			chunks: []*schema.Message{
+				{Content: "<think>需要先查看"},
+				{Content: "模块</think>"},
+				{ToolCalls: []schema.ToolCall{toolCallDelta(intPtr(0), "call_a", "viewModule", `{}`)}},
+			},
+			want: []ToolCall{{ID: "call_a", Name: "viewModule", Arguments: `{}`}},
+		},
+		{
+			name: "fenced json content",
+			chunks: []*schema.Message{
+				{Content: "```json\n{\"tool_calls\":[{\"id\":\"call_a\","},
+				{Content: "\"function\":{\"name\":\"viewModule\",\"arguments\":\"{}\"}}]}\n```"},
+			},
+			want: []ToolCall{{ID: "call_a", Name: "viewModule", Arguments: `{}`}},
+		},
+		{
+			name: "fenced json content after think prefix",
+			chunks: []*schema.Message{
+				{Content: "<think>调用工具</think>\n```json\n"},
+				{Content: "{\"tool_calls\":[{\"function\":{\"name\":\"viewModule\",\"arguments\":\"{}\"}}]}\n```"},
+			},
+			want: []ToolCall{{Name: "viewModule", Arguments: `{}`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := detectToolCalls(schema.StreamReaderFromArray(tt.chunks), tt.lateToolCalls, nil)
+			if err != nil {
+				t.Fatalf("detectToolCalls() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("detectToolCalls() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectToolCallsReportsDeltas(t *testing.T) {
+	chunks := []*schema.Message{
+		{ToolCalls: []schema.ToolCall{toolCallDelta(nil, "call_a", "addField", `{"a":`)}},
+		{ToolCalls: []schema.ToolCall{toolCallDelta(nil, "", "", `1}`)}},
+		{ToolCalls: []schema.ToolCall{toolCallDelta(nil, "call_b", "deleteField", `{}`)}},
+	}
+
+	var got []int
+	_, err := detectToolCalls(schema.StreamReaderFromArray(chunks), false, func(index int, _ schema.ToolCall) {
+		got = append(got, index)
+	})
+	if err != nil {
+		t.Fatalf("detectToolCalls() error = %v", err)
+	}
+	if want := []int{0, 0, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("delta indexes = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// Transcript collects the assistant tool calls and tool results produced while a run executes,
+// so that the caller can store the whole turn and not only the final answer
+type Transcript struct {
+	mu       sync.Mutex
+	messages []*schema.Message
+}
+
+// Messages returns the messages recorded so far in the order they were produced
+func (t *Transcript) Messages() []*schema.Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*schema.Message(nil), t.messages...)
+}
+
+type transcriptKey struct{}
+
+// WithTranscript returns a context that records the intermediate messages of a run into transcript
+func WithTranscript(ctx context.Context, transcript *Transcript) context.Context {
+	return context.WithValue(ctx, transcriptKey{}, transcript)
+}
+
+// recordMessages adds copies of messages to the transcript registered in the context, if any
+func recordMessages(ctx context.Context, messages ...*schema.Message) {
+	t, ok := ctx.Value(transcriptKey{}).(*Transcript)
+	if !ok || t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, msg := range messages {
+		if msg == nil {
+			continue
+		}
+		copied := *msg
+		t.messages = append(t.messages, &copied)
+	}
+}
@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync"
+
+	callbacks2 "github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/eino/utils/callbacks"
+
+	"coder/api"
+)
+
+// Usage accumulates the token usage reported by every model call of a single request,
+// including the calls made while looping over tool results
+type Usage struct {
+	mu    sync.Mutex
+	wg    sync.WaitGroup
+	total api.ChatResponseUsage
+	// 未上报用量的模型调用次数，部分服务的流式输出不返回用量
+	missing int
+}
+
+// add adds the token usage of one model call; nil records a call that reported no usage
+func (u *Usage) add(usage *model.TokenUsage) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if usage == nil {
+		u.missing++
+		return
+	}
+	u.total.PromptTokens += usage.PromptTokens
+	u.total.CompletionTokens += usage.CompletionTokens
+	u.total.TotalTokens += usage.TotalTokens
+}
+
+// Total waits for pending streamed model outputs and returns the accumulated usage
+func (u *Usage) Total() api.ChatResponseUsage {
+	u.wg.Wait()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.total
+}
+
+// Reported waits for pending streamed model outputs and reports whether every model call reported its usage,
+// so that Total is complete
+func (u *Usage) Reported() bool {
+	u.wg.Wait()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.missing == 0
+}
+
+// callbackHandler returns a callback handler that collects token usage from chat model outputs
+func (u *Usage) callbackHandler() callbacks2.Handler {
+	modelHandler := &callbacks.ModelCallbackHandler{
+		OnEnd: func(ctx context.Context, info *callbacks2.RunInfo, output *model.CallbackOutput) context.Context {
+			u.add(output.TokenUsage)
+			return ctx
+		},
+		OnEndWithStreamOutput: func(ctx context.Context, info *callbacks2.RunInfo, output *schema.StreamReader[*model.CallbackOutput]) context.Context {
+			u.wg.Add(1)
+			go func() {
+				defer u.wg.Done()
+				defer output.Close()
+
+				// 流式输出中用量通常只出现在最后一个分片，取最后一次上报的用量
+				var last *model.TokenUsage
+				for {
+					chunk, err := output.Recv()
+					if errors.Is(err, io.EOF) {
+						break
+					}
+					if err != nil {
+						log.Printf("Error receiving model output for usage: %v", err)
+						break
+					}
+					if chunk.TokenUsage != nil {
+						last = chunk.TokenUsage
+					}
+				}
+				u.add(last)
+			}()
+			return ctx
+		},
+	}
+
+	helper := callbacks.NewHandlerHelper()
+	helper.ChatModel(modelHandler)
+	return helper.Handler()
+}
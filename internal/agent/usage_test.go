@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"coder/api"
+)
+
+func TestUsageStreamedOutput(t *testing.T) {
+	tests := []struct {
+		name         string
+		chunks       []*model.CallbackOutput
+		wantReported bool
+		want         api.ChatResponseUsage
+	}{
+		{
+			name: "usage in the last chunk",
+			chunks: []*model.CallbackOutput{
+				{Message: schema.AssistantMessage("你", nil)},
+				{Message: schema.AssistantMessage("好", nil), TokenUsage: &model.TokenUsage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12}},
+			},
+			wantReported: true,
+			want:         api.ChatResponseUsage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12},
+		},
+		{
+			name: "no usage in the stream",
+			chunks: []*model.CallbackOutput{
+				{Message: schema.AssistantMessage("你", nil)},
+				{Message: schema.AssistantMessage("好", nil)},
+			},
+			wantReported: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage := &Usage{}
+			outputs := make([]callbacks.CallbackOutput, 0, len(tt.chunks))
+			for _, chunk := range tt.chunks {
+				outputs = append(outputs, chunk)
+			}
+			info := &callbacks.RunInfo{Component: components.ComponentOfChatModel}
+			usage.callbackHandler().OnEndWithStreamOutput(context.Background(), info, schema.StreamReaderFromArray(outputs))
+
+			if got := usage.Reported(); got != tt.wantReported {
+				t.Errorf("Reported() = %v, want %v", got, tt.wantReported)
+			}
+			if got := usage.Total(); got != tt.want {
+				t.Errorf("Total() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltStore keeps cache items in a bucket of an embedded bbolt database so that they survive restarts.
+// Items are stored as JSON, so T must be serializable.
+type BoltStore[T any] struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+// NewBoltStore creates a store using the given bucket of db, creating the bucket if needed
+func NewBoltStore[T any](db *bolt.DB, bucket string) (*BoltStore[T], error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bucket %s: %w", bucket, err)
+	}
+	return &BoltStore[T]{db: db, bucket: []byte(bucket)}, nil
+}
+
+// Get returns the item stored under key
+func (s *BoltStore[T]) Get(key string) (Item[T], bool, error) {
+	var item Item[T]
+	var found bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(s.bucket).Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &item)
+	})
+	if err != nil {
+		return item, false, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return item, found, nil
+}
+
+// Set stores an item under key
+func (s *BoltStore[T]) Set(key string, item Item[T]) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", key, err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Put([]byte(key), data)
+	})
+}
+
+// Delete removes the item stored under key
+func (s *BoltStore[T]) Delete(key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Delete([]byte(key))
+	})
+}
+
+// DeleteExpired removes the items that expired before now
+func (s *BoltStore[T]) DeleteExpired(now int64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		var expired [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			var item struct {
+				Expiration int64 `json:"expiration"`
+			}
+			if err := json.Unmarshal(v, &item); err != nil || item.Expiration > 0 && now > item.Expiration {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
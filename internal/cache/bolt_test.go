@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// openBoltStore opens the bolt database at path and a store on its modules bucket
+func openBoltStore(t *testing.T, path string) (*bolt.DB, *BoltStore[*ModuleCacheData]) {
+	t.Helper()
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	store, err := NewBoltStore[*ModuleCacheData](db, "modules")
+	if err != nil {
+		db.Close()
+		t.Fatalf("NewBoltStore() error = %v", err)
+	}
+	return db, store
+}
+
+// testDraft is a module draft with a recorded change
+func testDraft() *ModuleCacheData {
+	createdAt := time.Date(2026, 10, 17, 8, 30, 0, 0, time.UTC)
+	return &ModuleCacheData{
+		ModuleName: "用户",
+		ModuleCode: "user",
+		Support:    `{"createFields":[{"field":"name"}]}`,
+		Original:   `{"createFields":[]}`,
+		Version:    "v1",
+		Cur:        `{"createFields":[{"field":"name","label":"姓名"}]}`,
+		CachedAt:   createdAt,
+		Undo: []ModuleRevision{{
+			ID:        1,
+			Tool:      "addField",
+			Summary:   "Field 'name' has been added",
+			Before:    `{"createFields":[]}`,
+			After:     `{"createFields":[{"field":"name","label":"姓名"}]}`,
+			CreatedAt: createdAt,
+		}},
+	}
+}
+
+// assertSameDraft compares drafts by their JSON encoding, which is what the bolt store keeps
+func assertSameDraft(t *testing.T, got, want *ModuleCacheData) {
+	t.Helper()
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("draft = %s, want %s", gotJSON, wantJSON)
+	}
+}
+
+func TestBoltStoreRoundTrip(t *testing.T) {
+	db, store := openBoltStore(t, filepath.Join(t.TempDir(), "drafts.db"))
+	defer db.Close()
+	c := NewWithStore[*ModuleCacheData](store)
+
+	if _, ok := c.Get("s1/module/user"); ok {
+		t.Fatalf("Get() found a draft in an empty store")
+	}
+
+	draft := testDraft()
+	c.Set("s1/module/user", draft, time.Hour)
+	got, ok := c.Get("s1/module/user")
+	if !ok {
+		t.Fatalf("Get() did not find the stored draft")
+	}
+	assertSameDraft(t, got, draft)
+	// 存储的是副本，取出的草稿不与存入的共享
+	if got == draft {
+		t.Errorf("Get() returned the stored pointer, want a decoded copy")
+	}
+
+	c.Delete("s1/module/user")
+	if _, ok := c.Get("s1/module/user"); ok {
+		t.Errorf("Get() found a deleted draft")
+	}
+}
+
+func TestBoltStoreExpiration(t *testing.T) {
+	db, store := openBoltStore(t, filepath.Join(t.TempDir(), "drafts.db"))
+	defer db.Close()
+	c := NewWithStore[*ModuleCacheData](store)
+
+	now := time.Now()
+	items := map[string]int64{
+		"expired": now.Add(-time.Minute).UnixNano(),
+		"live":    now.Add(time.Hour).UnixNano(),
+		"forever": 0,
+	}
+	for key, expiration := range items {
+		if err := store.Set(key, Item[*ModuleCacheData]{Value: testDraft(), Expiration: expiration}); err != nil {
+			t.Fatalf("Set(%s) error = %v", key, err)
+		}
+	}
+	// 无法解码的条目也由清理删除
+	if err := db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte("modules")).Put([]byte("corrupt"), []byte("{"))
+	}); err != nil {
+		t.Fatalf("failed to store a corrupt item: %v", err)
+	}
+
+	// 过期的条目在清理前已不可见
+	if _, ok := c.Get("expired"); ok {
+		t.Errorf("Get() returned an expired draft")
+	}
+	for _, key := range []string{"live", "forever"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("Get(%s) did not find a live draft", key)
+		}
+	}
+
+	if err := store.DeleteExpired(now.UnixNano()); err != nil {
+		t.Fatalf("DeleteExpired() error = %v", err)
+	}
+	for key, want := range map[string]bool{"expired": false, "corrupt": false, "live": true, "forever": true} {
+		var found bool
+		if err := db.View(func(tx *bolt.Tx) error {
+			found = tx.Bucket([]byte("modules")).Get([]byte(key)) != nil
+			return nil
+		}); err != nil {
+			t.Fatalf("failed to read %s: %v", key, err)
+		}
+		if found != want {
+			t.Errorf("after DeleteExpired() %s stored = %v, want %v", key, found, want)
+		}
+	}
+}
+
+func TestBoltStoreReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drafts.db")
+	draft := testDraft()
+
+	db, store := openBoltStore(t, path)
+	NewWithStore[*ModuleCacheData](store).Set("s1/module/user", draft, time.Hour)
+	want, _, err := store.Get("s1/module/user")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("failed to close %s: %v", path, err)
+	}
+
+	// 重新打开后草稿及其过期时间都保留
+	db, store = openBoltStore(t, path)
+	defer db.Close()
+	item, found, err := store.Get("s1/module/user")
+	if err != nil || !found {
+		t.Fatalf("Get() after reopening = %v, %v, want the stored draft", found, err)
+	}
+	if item.Expiration != want.Expiration {
+		t.Errorf("expiration after reopening = %d, want %d", item.Expiration, want.Expiration)
+	}
+	assertSameDraft(t, item.Value, draft)
+}
+
+func TestDraftExpiration(t *testing.T) {
+	tests := []struct {
+		name           string
+		draftTTL       time.Duration
+		backendDefault time.Duration
+		want           time.Duration
+	}{
+		{name: "memory default", draftTTL: 0, backendDefault: defaultMemoryDraftExpiration, want: 24 * time.Hour},
+		{name: "bolt default is finite", draftTTL: 0, backendDefault: defaultBoltDraftExpiration, want: 7 * 24 * time.Hour},
+		{name: "configured", draftTTL: 2 * time.Hour, backendDefault: defaultBoltDraftExpiration, want: 2 * time.Hour},
+		{name: "negative keeps drafts forever", draftTTL: -time.Hour, backendDefault: defaultBoltDraftExpiration, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := draftExpiration(tt.draftTTL, tt.backendDefault); got != tt.want {
+				t.Errorf("draftExpiration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,82 +1,111 @@
 package cache
 
 import (
+	"log"
 	"sync"
 	"time"
 )
 
 // Item represents a cached item with expiration
-type Item struct {
-	Value      interface{}
-	Expiration int64
+type Item[T any] struct {
+	Value      T     `json:"value"`
+	Expiration int64 `json:"expiration"`
 }
 
 // IsExpired returns true if the item has expired
-func (item Item) IsExpired() bool {
+func (item Item[T]) IsExpired() bool {
 	if item.Expiration == 0 {
 		return false
 	}
 	return time.Now().UnixNano() > item.Expiration
 }
 
-// Cache is a concurrent map with expiring items
-type Cache struct {
-	items map[string]Item
+// Store is the storage backend of a Cache
+type Store[T any] interface {
+	Get(key string) (Item[T], bool, error)
+	Set(key string, item Item[T]) error
+	Delete(key string) error
+	// DeleteExpired removes the items that expired before now (unix nanoseconds)
+	DeleteExpired(now int64) error
+}
+
+// Cache is a typed key value cache with expiring items kept in a pluggable store
+type Cache[T any] struct {
+	store Store[T]
 	mu    sync.RWMutex
 }
 
-// New creates a new cache
-func New() *Cache {
-	cache := &Cache{
-		items: make(map[string]Item),
+// New creates a new cache backed by memory
+func New[T any]() *Cache[T] {
+	return NewWithStore[T](NewMemoryStore[T]())
+}
+
+// NewWithStore creates a new cache backed by the given store
+func NewWithStore[T any](store Store[T]) *Cache[T] {
+	cache := &Cache[T]{
+		store: store,
 	}
 	go cache.janitor()
 	return cache
 }
 
+// SetStore replaces the store of the cache; items of the previous store are not copied
+func (c *Cache[T]) SetStore(store Store[T]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store = store
+}
+
 // Set adds an item to the cache with the given expiration duration
-func (c *Cache) Set(key string, value interface{}, duration time.Duration) {
+func (c *Cache[T]) Set(key string, value T, duration time.Duration) {
 	var expiration int64
 	if duration > 0 {
 		expiration = time.Now().Add(duration).UnixNano()
 	}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
-	c.items[key] = Item{
-		Value:      value,
-		Expiration: expiration,
+	if err := c.store.Set(key, Item[T]{Value: value, Expiration: expiration}); err != nil {
+		log.Printf("Failed to store cache item %s: %v", key, err)
 	}
 }
 
 // Get retrieves an item from the cache
-func (c *Cache) Get(key string) (interface{}, bool) {
+func (c *Cache[T]) Get(key string) (T, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	item, found := c.items[key]
+	var zero T
+	item, found, err := c.store.Get(key)
+	if err != nil {
+		log.Printf("Failed to load cache item %s: %v", key, err)
+		return zero, false
+	}
 	if !found {
-		return nil, false
+		return zero, false
 	}
 
 	// Check if the item has expired
 	if item.IsExpired() {
-		return nil, false
+		return zero, false
 	}
 
 	return item.Value, true
 }
 
 // Delete removes an item from the cache
-func (c *Cache) Delete(key string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	delete(c.items, key)
+func (c *Cache[T]) Delete(key string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if err := c.store.Delete(key); err != nil {
+		log.Printf("Failed to delete cache item %s: %v", key, err)
+	}
 }
 
 // janitor periodically cleans up expired items
-func (c *Cache) janitor() {
+func (c *Cache[T]) janitor() {
 	ticker := time.NewTicker(time.Minute)
 	defer ticker.Stop()
 
@@ -87,15 +116,11 @@ func (c *Cache) janitor() {
 }
 
 // deleteExpired deletes expired items from the cache
-func (c *Cache) deleteExpired() {
-	now := time.Now().UnixNano()
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
+func (c *Cache[T]) deleteExpired() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
-	for k, v := range c.items {
-		if v.Expiration > 0 && now > v.Expiration {
-			delete(c.items, k)
-		}
+	if err := c.store.DeleteExpired(time.Now().UnixNano()); err != nil {
+		log.Printf("Failed to delete expired cache items: %v", err)
 	}
 }
@@ -3,6 +3,7 @@ package cache
 import (
 	"coder/api"
 	"coder/internal/config"
+	"coder/internal/envelope"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -12,10 +13,10 @@ import (
 
 // EntityCacheData represents the cached entity configuration data
 type EntityCacheData struct {
-	EntityName string    // Entity name
-	Attributes string    // JSON string of entity attributes
-	Config     string    // JSON string of entity configuration
-	CachedAt   time.Time // When the data was cached
+	EntityName string    `json:"entityName"` // Entity name
+	Attributes string    `json:"attributes"` // JSON string of entity attributes
+	Config     string    `json:"config"`     // JSON string of entity configuration
+	CachedAt   time.Time `json:"cachedAt"`   // When the data was cached
 }
 
 // NewEntityCacheData creates a new entity cache entry
@@ -28,7 +29,9 @@ func NewEntityCacheData(entityName, attributes, config string) *EntityCacheData
 	}
 }
 
-func DecodeEntityFromCtx(ctx context.Context) (*EntityCacheData, map[string]interface{}, map[string]interface{}, *api.ChatRequest, error) {
+// DecodeEntityFromCtx returns the entity draft with the given name, or the active entity when the name is empty,
+// of the conversation in ctx with its decoded attributes and configuration
+func DecodeEntityFromCtx(ctx context.Context, entityName string) (*EntityCacheData, map[string]interface{}, map[string]interface{}, *api.ChatRequest, error) {
 	userReq, ok := ctx.Value(config.StateKey).(*api.ChatRequest)
 	if !ok {
 		return nil, nil, nil, nil, fmt.Errorf("state not found in context")
@@ -36,25 +39,27 @@ func DecodeEntityFromCtx(ctx context.Context) (*EntityCacheData, map[string]inte
 	log.Printf("Processing entity config in message: %v", userReq)
 
 	// Get cache
-	cacheKey := CacheKey(userReq.ConversationID)
-	info, ok := EntityCacheInstance.Get(cacheKey)
+	cacheKey, err := ResolveEntityKey(userReq.ConversationID, entityName)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	infoCache, ok := EntityCacheInstance.Get(cacheKey)
 	if !ok {
-		return nil, nil, nil, nil, fmt.Errorf("failed to get cache")
+		return nil, nil, nil, nil, envelope.Errorf(envelope.CodeNotFound, "failed to get cache, generate the entity with genField first")
 	}
 
-	infoCache := info.(*EntityCacheData)
 	attributes := make(map[string]interface{})
-	err := json.Unmarshal([]byte(infoCache.Attributes), &attributes)
+	err = json.Unmarshal([]byte(infoCache.Attributes), &attributes)
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("failed to unmarshal attributes: %w", err)
+		return nil, nil, nil, nil, envelope.Errorf(envelope.CodeInvalidConfig, "failed to unmarshal attributes: %w", err)
 	}
 	config := make(map[string]interface{})
 	err = json.Unmarshal([]byte(infoCache.Config), &config)
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("failed to unmarshal config: %w", err)
+		return nil, nil, nil, nil, envelope.Errorf(envelope.CodeInvalidConfig, "failed to unmarshal config: %w", err)
 	}
 	return infoCache, attributes, config, userReq, nil
 }
 
 // Global cache instance for entities
-var EntityCacheInstance = New()
+var EntityCacheInstance = New[*EntityCacheData]()
@@ -0,0 +1,56 @@
+package cache
+
+import "sync"
+
+// MemoryStore keeps cache items in a map; items are lost when the process exits
+type MemoryStore[T any] struct {
+	items map[string]Item[T]
+	mu    sync.RWMutex
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore[T any]() *MemoryStore[T] {
+	return &MemoryStore[T]{
+		items: make(map[string]Item[T]),
+	}
+}
+
+// Get returns the item stored under key
+func (s *MemoryStore[T]) Get(key string) (Item[T], bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	item, found := s.items[key]
+	return item, found, nil
+}
+
+// Set stores an item under key
+func (s *MemoryStore[T]) Set(key string, item Item[T]) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items[key] = item
+	return nil
+}
+
+// Delete removes the item stored under key
+func (s *MemoryStore[T]) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.items, key)
+	return nil
+}
+
+// DeleteExpired removes the items that expired before now
+func (s *MemoryStore[T]) DeleteExpired(now int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, v := range s.items {
+		if v.Expiration > 0 && now > v.Expiration {
+			delete(s.items, k)
+		}
+	}
+	return nil
+}
@@ -3,37 +3,52 @@ package cache
 import (
 	"coder/api"
 	"coder/internal/config"
+	"coder/internal/moduleconfig"
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"time"
 )
 
-// DefaultCacheExpiration is the default expiration time for cached module data
+// DefaultCacheExpiration is the expiration time of short-lived cached data such as paused runs; drafts use
+// DraftExpiration
 const DefaultCacheExpiration = 1 * time.Hour
 
 // ModuleCacheData represents the cached module data
 type ModuleCacheData struct {
-	ModuleName string    // Module name
-	ModuleCode string    // Module code
-	Support    string    // Module configuration
-	Cur        string    // Module configuration
-	CachedAt   time.Time // When the data was cached
+	ModuleName string    `json:"moduleName"` // Module name
+	ModuleCode string    `json:"moduleCode"` // Module code
+	Support    string    `json:"support"`    // Module configuration
+	Original   string    `json:"original"`   // Module configuration saved on the server, empty when never saved
+	Version    string    `json:"version"`    // Version of Original, used to detect concurrent saves
+	Cur        string    `json:"cur"`        // Module configuration
+	CachedAt   time.Time `json:"cachedAt"`   // When the data was cached
+
+	Undo []ModuleRevision `json:"undo,omitempty"` // Changes that can be undone, oldest first
+	Redo []ModuleRevision `json:"redo,omitempty"` // Undone changes that can be redone, oldest first
 }
 
 // NewModuleCacheData creates a new module cache entry
-func NewModuleCacheData(moduleName, moduleCode, support, cur string) *ModuleCacheData {
+func NewModuleCacheData(moduleName, moduleCode, support, original, cur string) *ModuleCacheData {
 	return &ModuleCacheData{
 		ModuleName: moduleName,
 		ModuleCode: moduleCode,
 		Support:    support,
+		Original:   original,
 		Cur:        cur,
 		CachedAt:   time.Now(),
 	}
 }
 
-func DecodeModuleFromCtx(ctx context.Context) (*ModuleCacheData, map[string]interface{}, map[string]interface{}, *api.ChatRequest, error) {
+// LoadedVersion returns the version of the server configuration the draft is based on, hashing Original when the
+// config service reported no version
+func (d *ModuleCacheData) LoadedVersion() string {
+	return moduleconfig.LoadedVersion(d.Version, d.Original)
+}
+
+// DecodeModuleFromCtx returns the module draft with the given code, or the active module when the code is empty,
+// of the conversation in ctx with its decoded current and supported configurations
+func DecodeModuleFromCtx(ctx context.Context, moduleCode string) (*ModuleCacheData, *moduleconfig.Config, *moduleconfig.Config, *api.ChatRequest, error) {
 	// In a real implementation, we would edit the action in the module
 	userReq, ok := ctx.Value(config.StateKey).(*api.ChatRequest)
 	if !ok {
@@ -42,30 +57,47 @@ func DecodeModuleFromCtx(ctx context.Context) (*ModuleCacheData, map[string]inte
 	log.Printf("Processing LocalTool calls in message: %v", userReq)
 
 	// Get cache
-	cacheKey := CacheKey(userReq.ConversationID)
-	info, ok := ModuleCacheInstance.Get(cacheKey)
-	if !ok {
-		return nil, nil, nil, nil, fmt.Errorf("failed to get cache")
+	_, infoCache, err := GetModuleDraft(userReq.ConversationID, moduleCode)
+	if err != nil {
+		return nil, nil, nil, nil, err
 	}
 
-	infoCache := info.(*ModuleCacheData)
-	mapCur := make(map[string]interface{})
-	err := json.Unmarshal([]byte(infoCache.Cur), &mapCur)
+	cur, err := moduleconfig.Parse(infoCache.Cur)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to parse cur: %w", err)
+	}
+	support, err := moduleconfig.Parse(infoCache.Support)
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("failed to unmarshal cur: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("failed to parse support: %w", err)
 	}
-	mapSupport := make(map[string]interface{})
-	err = json.Unmarshal([]byte(infoCache.Support), &mapSupport)
+	return infoCache, cur, support, userReq, nil
+}
+
+// CommitModuleConfig validates cfg as the next configuration of the module draft and records it as a change made by
+// tool; problems the draft already had are tolerated, new ones reject the change. Nothing is recorded once ctx is
+// done, so a tool that outlived its timeout cannot change the draft
+func CommitModuleConfig(ctx context.Context, sessionID string, draft *ModuleCacheData, cfg *moduleconfig.Config, tool, summary string) error {
+	// 当前草稿无法解析时，新配置的所有问题都需要修复
+	before, _ := moduleconfig.Parse(draft.Cur)
+	support, err := moduleconfig.Parse(draft.Support)
+	if err != nil {
+		return fmt.Errorf("failed to parse support: %w", err)
+	}
+	if err := moduleconfig.ValidateChange(before, cfg, support); err != nil {
+		return err
+	}
+
+	cur, err := cfg.JSON()
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("failed to unmarshal support: %w", err)
+		return err
 	}
-	return infoCache, mapCur, mapSupport, userReq, nil
+	return RecordModuleChange(ctx, ModuleKey(sessionID, draft.ModuleCode), draft, cur, tool, summary)
 }
 
 // Global cache instance for modules
-var ModuleCacheInstance = New()
+var ModuleCacheInstance = New[*ModuleCacheData]()
 
-// CacheKey generates a cache key for a module
+// CacheKey generates the cache key of a conversation workspace
 func CacheKey(sessionID string) string {
 	return sessionID
 }
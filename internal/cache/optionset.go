@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"context"
+	"fmt"
+)
+
+// GetOptionSet returns the option set with the given name from the workspace of a conversation
+func GetOptionSet(sessionID, name string) (moduleconfig.OptionSet, error) {
+	ws := GetWorkspace(sessionID)
+	for _, set := range ws.OptionSets {
+		if set.Name == name {
+			return set, nil
+		}
+	}
+	return moduleconfig.OptionSet{}, envelope.Errorf(envelope.CodeNotFound, "option set '%s' does not exist, create it with createOptionSet first (option sets: %v)", name, OptionSetNames(ws))
+}
+
+// SaveOptionSet stores an option set in the workspace of a conversation, replacing the set with the same name; it
+// fails with the error of ctx once ctx is done
+func SaveOptionSet(ctx context.Context, sessionID string, set moduleconfig.OptionSet) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("option set '%s' was not saved: %w", set.Name, err)
+	}
+	updateWorkspace(sessionID, func(ws *Workspace) {
+		for i := range ws.OptionSets {
+			if ws.OptionSets[i].Name == set.Name {
+				ws.OptionSets[i] = set
+				return
+			}
+		}
+		ws.OptionSets = append(ws.OptionSets, set)
+	})
+	return nil
+}
+
+// OptionSetNames lists the names of the option sets of a workspace
+func OptionSetNames(ws *Workspace) []string {
+	names := make([]string, 0, len(ws.OptionSets))
+	for _, set := range ws.OptionSets {
+		names = append(names, set.Name)
+	}
+	return names
+}
+
+// RebindOptionSet refreshes the fields bound to the option set in every module open in the workspace of a
+// conversation, recording a change made by tool in each module it updates, and returns the updated fields by module
+func RebindOptionSet(ctx context.Context, sessionID string, set moduleconfig.OptionSet, tool string) (map[string][]string, error) {
+	updated := make(map[string][]string)
+	for _, code := range GetWorkspace(sessionID).Modules {
+		_, draft, err := GetModuleDraft(sessionID, code)
+		if err != nil {
+			continue
+		}
+		cur, err := moduleconfig.Parse(draft.Cur)
+		if err != nil {
+			return updated, fmt.Errorf("failed to parse cur of module '%s': %w", code, err)
+		}
+		fields := moduleconfig.RebindOptionSet(cur, set)
+		if len(fields) == 0 {
+			continue
+		}
+		summary := fmt.Sprintf("Options of option set '%s' have been refreshed in %v", set.Name, fields)
+		if err := CommitModuleConfig(ctx, sessionID, draft, cur, tool, summary); err != nil {
+			return updated, err
+		}
+		updated[code] = fields
+	}
+	return updated, nil
+}
@@ -0,0 +1,231 @@
+package cache
+
+import (
+	"coder/internal/envelope"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxModuleRevisions is the number of revisions kept for undo per module draft
+const maxModuleRevisions = 50
+
+// ModuleRevision records one change made to a module draft
+type ModuleRevision struct {
+	ID        int       `json:"id"`        // Revision number, increasing per draft
+	Tool      string    `json:"tool"`      // Tool that made the change
+	Summary   string    `json:"summary"`   // Description of the change
+	Before    string    `json:"before"`    // Module configuration before the change
+	After     string    `json:"after"`     // Module configuration after the change
+	CreatedAt time.Time `json:"createdAt"` // When the change was made
+}
+
+// ModuleChange is the listing view of a revision without the configurations
+type ModuleChange struct {
+	ID        int       `json:"id"`
+	Tool      string    `json:"tool"`
+	Summary   string    `json:"summary"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ModuleChanges lists the revisions that can be undone and redone, most recent first
+type ModuleChanges struct {
+	ModuleName string         `json:"moduleName"`
+	ModuleCode string         `json:"moduleCode"`
+	Undo       []ModuleChange `json:"undo"`
+	Redo       []ModuleChange `json:"redo"`
+}
+
+// revisionMu serializes the read-modify-write of module drafts done by revisions
+var revisionMu sync.Mutex
+
+// RecordModuleChange stores cur, built from draft, as the new configuration of the module draft under key and records
+// the change for undo; it fails with a conflict when the draft changed since it was read and with the error of ctx
+// once ctx is done
+func RecordModuleChange(ctx context.Context, key string, draft *ModuleCacheData, cur, tool, summary string) error {
+	revisionMu.Lock()
+	defer revisionMu.Unlock()
+
+	next, err := nextRevision(ctx, key, draft, cur, tool, summary)
+	if err != nil {
+		return err
+	}
+	ModuleCacheInstance.Set(key, next, DraftExpiration)
+	return nil
+}
+
+// RebaseModuleChange stores merged as the configuration of the module draft under key, now based on the server
+// configuration original with the given version, and records the change for undo; it fails with a conflict when the
+// draft changed since it was read and with the error of ctx once ctx is done
+func RebaseModuleChange(ctx context.Context, key string, draft *ModuleCacheData, original, version, merged, tool, summary string) error {
+	revisionMu.Lock()
+	defer revisionMu.Unlock()
+
+	next, err := nextRevision(ctx, key, draft, merged, tool, summary)
+	if err != nil {
+		return err
+	}
+	next.Original = original
+	next.Version = version
+	ModuleCacheInstance.Set(key, next, DraftExpiration)
+	return nil
+}
+
+// nextRevision returns a copy of the draft with cur applied and the change recorded; revisionMu must be held.
+// cur was built from draft, so the change is refused when the stored draft has moved on since draft was read.
+func nextRevision(ctx context.Context, key string, draft *ModuleCacheData, cur, tool, summary string) (*ModuleCacheData, error) {
+	// 工具超时或被取消后结果已被丢弃，不能再修改草稿
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("module '%s' was not changed: %w", draft.ModuleCode, err)
+	}
+	// 工具读取草稿后又有其他修改（并发调用、撤销或重做）时拒绝覆盖
+	if latest, ok := ModuleCacheInstance.Get(key); ok && latest.ModuleCode == draft.ModuleCode {
+		if !latest.sameRevision(draft) {
+			return nil, envelope.Errorf(envelope.CodeConflict, "module '%s' was changed by another call (now at revision %d) after this change was prepared, reload it and try again", draft.ModuleCode, latest.lastRevisionID())
+		}
+		draft = latest
+	}
+
+	next := draft.clone()
+	revision := ModuleRevision{
+		ID:        draft.lastRevisionID() + 1,
+		Tool:      tool,
+		Summary:   summary,
+		Before:    draft.Cur,
+		After:     cur,
+		CreatedAt: time.Now(),
+	}
+	next.Cur = cur
+	next.Undo = append(next.Undo, revision)
+	if len(next.Undo) > maxModuleRevisions {
+		next.Undo = next.Undo[len(next.Undo)-maxModuleRevisions:]
+	}
+	// 新的修改会使已撤销的修改无法再重做
+	next.Redo = nil
+	next.CachedAt = time.Now()
+	return next, nil
+}
+
+// MarkModuleSaved records saved and its version as the configuration now stored on the server for the module draft
+// under key; it fails with the error of ctx once ctx is done
+func MarkModuleSaved(ctx context.Context, key string, saved, version string) error {
+	revisionMu.Lock()
+	defer revisionMu.Unlock()
+
+	draft, ok := ModuleCacheInstance.Get(key)
+	if !ok {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("module '%s' was not marked as saved: %w", draft.ModuleCode, err)
+	}
+	next := draft.clone()
+	next.Original = saved
+	next.Version = version
+	ModuleCacheInstance.Set(key, next, DraftExpiration)
+	return nil
+}
+
+// UndoModuleChange restores the configuration before the last change of the module draft under key; it fails with the
+// error of ctx once ctx is done
+func UndoModuleChange(ctx context.Context, key string) (*ModuleCacheData, *ModuleRevision, error) {
+	revisionMu.Lock()
+	defer revisionMu.Unlock()
+
+	draft, ok := ModuleCacheInstance.Get(key)
+	if !ok {
+		return nil, nil, envelope.Errorf(envelope.CodeNotFound, "failed to get cache, load the module with viewModule first")
+	}
+	if len(draft.Undo) == 0 {
+		return nil, nil, envelope.Errorf(envelope.CodeNotFound, "there is no change to undo")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, fmt.Errorf("change of module '%s' was not undone: %w", draft.ModuleCode, err)
+	}
+
+	next := draft.clone()
+	revision := next.Undo[len(next.Undo)-1]
+	next.Undo = next.Undo[:len(next.Undo)-1]
+	next.Redo = append(next.Redo, revision)
+	next.Cur = revision.Before
+	next.CachedAt = time.Now()
+	ModuleCacheInstance.Set(key, next, DraftExpiration)
+	return next, &revision, nil
+}
+
+// RedoModuleChange reapplies the last undone change of the module draft under key; it fails with the
+// error of ctx once ctx is done
+func RedoModuleChange(ctx context.Context, key string) (*ModuleCacheData, *ModuleRevision, error) {
+	revisionMu.Lock()
+	defer revisionMu.Unlock()
+
+	draft, ok := ModuleCacheInstance.Get(key)
+	if !ok {
+		return nil, nil, envelope.Errorf(envelope.CodeNotFound, "failed to get cache, load the module with viewModule first")
+	}
+	if len(draft.Redo) == 0 {
+		return nil, nil, envelope.Errorf(envelope.CodeNotFound, "there is no undone change to redo")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, fmt.Errorf("change of module '%s' was not redone: %w", draft.ModuleCode, err)
+	}
+
+	next := draft.clone()
+	revision := next.Redo[len(next.Redo)-1]
+	next.Redo = next.Redo[:len(next.Redo)-1]
+	next.Undo = append(next.Undo, revision)
+	next.Cur = revision.After
+	next.CachedAt = time.Now()
+	ModuleCacheInstance.Set(key, next, DraftExpiration)
+	return next, &revision, nil
+}
+
+// ListModuleChanges returns the changes of the module draft under key that can be undone and redone
+func ListModuleChanges(key string) (*ModuleChanges, error) {
+	draft, ok := ModuleCacheInstance.Get(key)
+	if !ok {
+		return nil, envelope.Errorf(envelope.CodeNotFound, "failed to get cache, load the module with viewModule first")
+	}
+	return &ModuleChanges{
+		ModuleName: draft.ModuleName,
+		ModuleCode: draft.ModuleCode,
+		Undo:       listChanges(draft.Undo),
+		Redo:       listChanges(draft.Redo),
+	}, nil
+}
+
+// listChanges converts revisions to changes, most recent first
+func listChanges(revisions []ModuleRevision) []ModuleChange {
+	changes := make([]ModuleChange, 0, len(revisions))
+	for i := len(revisions) - 1; i >= 0; i-- {
+		r := revisions[i]
+		changes = append(changes, ModuleChange{ID: r.ID, Tool: r.Tool, Summary: r.Summary, CreatedAt: r.CreatedAt})
+	}
+	return changes
+}
+
+// clone copies the draft so that stored drafts are never modified in place
+func (d *ModuleCacheData) clone() *ModuleCacheData {
+	c := *d
+	c.Undo = append([]ModuleRevision(nil), d.Undo...)
+	c.Redo = append([]ModuleRevision(nil), d.Redo...)
+	return &c
+}
+
+// sameRevision reports whether the two copies of a draft have the same configuration and change history
+func (d *ModuleCacheData) sameRevision(other *ModuleCacheData) bool {
+	return d.Cur == other.Cur && len(d.Undo) == len(other.Undo) && len(d.Redo) == len(other.Redo) && d.lastRevisionID() == other.lastRevisionID()
+}
+
+// lastRevisionID returns the highest revision number of the draft
+func (d *ModuleCacheData) lastRevisionID() int {
+	id := 0
+	for _, r := range d.Undo {
+		id = max(id, r.ID)
+	}
+	for _, r := range d.Redo {
+		id = max(id, r.ID)
+	}
+	return id
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// 草稿存储后端
+const (
+	BackendMemory = "memory" // 内存，重启后丢失
+	BackendBolt   = "bolt"   // 本地 bbolt 文件，重启后保留
+)
+
+// defaultBoltPath is the database file used by the bolt backend when no path is configured
+const defaultBoltPath = "data/drafts.db"
+
+// 未配置 draft_ttl 时草稿的过期时间：内存中保留一天，bolt 文件中保留一周，避免文件无限增长
+const (
+	defaultMemoryDraftExpiration = 24 * time.Hour
+	defaultBoltDraftExpiration   = 7 * 24 * time.Hour
+)
+
+// DraftExpiration is how long a module or entity draft outlives its last change; 0 keeps drafts until they are
+// closed. Init sets it from the configured draft TTL.
+var DraftExpiration time.Duration = defaultMemoryDraftExpiration
+
+// draftDB is the database opened by Init for the bolt backend
+var draftDB *bolt.DB
+
+// Init selects the storage backend of the module and entity draft caches and the conversation workspaces and how
+// long drafts are kept; a zero draftTTL uses the default of the backend and a negative one keeps drafts forever
+func Init(backend, path string, draftTTL time.Duration) error {
+	switch backend {
+	case "", BackendMemory:
+		DraftExpiration = draftExpiration(draftTTL, defaultMemoryDraftExpiration)
+		log.Printf("Using in-memory draft store, drafts expire after %v idle", DraftExpiration)
+		return nil
+	case BackendBolt:
+		DraftExpiration = draftExpiration(draftTTL, defaultBoltDraftExpiration)
+	default:
+		return fmt.Errorf("unknown cache backend %q", backend)
+	}
+
+	if path == "" {
+		path = defaultBoltPath
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create draft store directory: %w", err)
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return fmt.Errorf("failed to open draft store %s: %w", path, err)
+	}
+
+	moduleStore, err := NewBoltStore[*ModuleCacheData](db, "modules")
+	if err != nil {
+		db.Close()
+		return err
+	}
+	entityStore, err := NewBoltStore[*EntityCacheData](db, "entities")
+	if err != nil {
+		db.Close()
+		return err
+	}
+	workspaceStore, err := NewBoltStore[*Workspace](db, "workspaces")
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	ModuleCacheInstance.SetStore(moduleStore)
+	EntityCacheInstance.SetStore(entityStore)
+	WorkspaceInstance.SetStore(workspaceStore)
+	draftDB = db
+	log.Printf("Using draft store %s, drafts expire after %v idle (0 means never)", path, DraftExpiration)
+	return nil
+}
+
+// draftExpiration resolves a configured draft TTL against the default of the backend
+func draftExpiration(draftTTL, backendDefault time.Duration) time.Duration {
+	switch {
+	case draftTTL < 0:
+		return 0
+	case draftTTL == 0:
+		return backendDefault
+	}
+	return draftTTL
+}
+
+// Close closes the draft database opened by Init, if any
+func Close() {
+	if draftDB == nil {
+		return
+	}
+	if err := draftDB.Close(); err != nil {
+		log.Printf("Failed to close draft store: %v", err)
+	}
+	draftDB = nil
+}
@@ -0,0 +1,203 @@
+package cache
+
+import (
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Workspace holds the module and entity drafts open in a conversation and which of them are active
+type Workspace struct {
+	Modules      []string  `json:"modules"`      // Codes of the open module drafts, in opening order
+	Entities     []string  `json:"entities"`     // Names of the open entity drafts, in opening order
+	ActiveModule string    `json:"activeModule"` // Module edited when a tool is given no module_code
+	ActiveEntity string    `json:"activeEntity"` // Entity saved when saveEntity is given no entity_name
+	UpdatedAt    time.Time `json:"updatedAt"`
+
+	OptionSets []moduleconfig.OptionSet `json:"optionSets,omitempty"` // Option sets shared by the fields of the workspace
+}
+
+// WorkspaceExpiration is how long a workspace outlives its last update at least; it is kept as long as the drafts it
+// lists, which expire on their own
+const WorkspaceExpiration = 24 * time.Hour
+
+// Global cache instance for conversation workspaces
+var WorkspaceInstance = New[*Workspace]()
+
+// workspaceMu serializes the read-modify-write of workspaces
+var workspaceMu sync.Mutex
+
+// ModuleKey generates the cache key of a module draft in a conversation
+func ModuleKey(sessionID, moduleCode string) string {
+	return sessionID + "/module/" + moduleCode
+}
+
+// EntityKey generates the cache key of an entity draft in a conversation
+func EntityKey(sessionID, entityName string) string {
+	return sessionID + "/entity/" + entityName
+}
+
+// GetWorkspace returns the workspace of a conversation with drafts that expired removed
+func GetWorkspace(sessionID string) *Workspace {
+	ws, ok := WorkspaceInstance.Get(CacheKey(sessionID))
+	if !ok {
+		return &Workspace{Modules: []string{}, Entities: []string{}}
+	}
+
+	result := &Workspace{Modules: []string{}, Entities: []string{}, UpdatedAt: ws.UpdatedAt, OptionSets: ws.OptionSets}
+	for _, code := range ws.Modules {
+		if _, ok := ModuleCacheInstance.Get(ModuleKey(sessionID, code)); ok {
+			result.Modules = append(result.Modules, code)
+			if code == ws.ActiveModule {
+				result.ActiveModule = code
+			}
+		}
+	}
+	for _, name := range ws.Entities {
+		if _, ok := EntityCacheInstance.Get(EntityKey(sessionID, name)); ok {
+			result.Entities = append(result.Entities, name)
+			if name == ws.ActiveEntity {
+				result.ActiveEntity = name
+			}
+		}
+	}
+	return result
+}
+
+// OpenModule stores a module draft in the workspace of a conversation and makes it the active module; it fails
+// with the error of ctx once ctx is done
+func OpenModule(ctx context.Context, sessionID string, draft *ModuleCacheData) error {
+	// 工具超时或被取消后结果已被丢弃，不能再修改会话状态
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("module '%s' was not opened: %w", draft.ModuleCode, err)
+	}
+	ModuleCacheInstance.Set(ModuleKey(sessionID, draft.ModuleCode), draft, DraftExpiration)
+	updateWorkspace(sessionID, func(ws *Workspace) {
+		ws.Modules = appendUnique(ws.Modules, draft.ModuleCode)
+		ws.ActiveModule = draft.ModuleCode
+	})
+	return nil
+}
+
+// OpenEntity stores an entity draft in the workspace of a conversation and makes it the active entity; it fails
+// with the error of ctx once ctx is done
+func OpenEntity(ctx context.Context, sessionID, entityName string, draft *EntityCacheData) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("entity '%s' was not opened: %w", entityName, err)
+	}
+	EntityCacheInstance.Set(EntityKey(sessionID, entityName), draft, DraftExpiration)
+	updateWorkspace(sessionID, func(ws *Workspace) {
+		ws.Entities = appendUnique(ws.Entities, entityName)
+		ws.ActiveEntity = entityName
+	})
+	return nil
+}
+
+// SwitchModule makes an open module draft the active module of a conversation; it fails with the error of ctx once
+// ctx is done
+func SwitchModule(ctx context.Context, sessionID, moduleCode string) (*ModuleCacheData, error) {
+	key, err := ResolveModuleKey(sessionID, moduleCode)
+	if err != nil {
+		return nil, err
+	}
+	draft, ok := ModuleCacheInstance.Get(key)
+	if !ok {
+		return nil, envelope.Errorf(envelope.CodeNotFound, "module '%s' is not open, load it with viewModule first", moduleCode)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("active module was not switched to '%s': %w", moduleCode, err)
+	}
+	updateWorkspace(sessionID, func(ws *Workspace) {
+		ws.ActiveModule = moduleCode
+	})
+	return draft, nil
+}
+
+// ResolveModuleKey returns the cache key of the module draft with the given code, or of the active module when
+// the code is empty
+func ResolveModuleKey(sessionID, moduleCode string) (string, error) {
+	ws := GetWorkspace(sessionID)
+	if moduleCode == "" {
+		if ws.ActiveModule == "" {
+			return "", envelope.Errorf(envelope.CodeNotFound, "failed to get cache, load the module with viewModule first")
+		}
+		return ModuleKey(sessionID, ws.ActiveModule), nil
+	}
+	for _, code := range ws.Modules {
+		if code == moduleCode {
+			return ModuleKey(sessionID, code), nil
+		}
+	}
+	return "", envelope.Errorf(envelope.CodeNotFound, "module '%s' is not open, load it with viewModule first (open modules: %v)", moduleCode, ws.Modules)
+}
+
+// ResolveEntityKey returns the cache key of the entity draft with the given name, or of the active entity when
+// the name is empty
+func ResolveEntityKey(sessionID, entityName string) (string, error) {
+	ws := GetWorkspace(sessionID)
+	if entityName == "" {
+		if ws.ActiveEntity == "" {
+			return "", envelope.Errorf(envelope.CodeNotFound, "failed to get cache, generate the entity with genField first")
+		}
+		return EntityKey(sessionID, ws.ActiveEntity), nil
+	}
+	for _, name := range ws.Entities {
+		if name == entityName {
+			return EntityKey(sessionID, name), nil
+		}
+	}
+	return "", envelope.Errorf(envelope.CodeNotFound, "entity '%s' is not open, generate it with genField first (open entities: %v)", entityName, ws.Entities)
+}
+
+// GetModuleDraft returns the cache key and the module draft with the given code, or the active module when the
+// code is empty
+func GetModuleDraft(sessionID, moduleCode string) (string, *ModuleCacheData, error) {
+	key, err := ResolveModuleKey(sessionID, moduleCode)
+	if err != nil {
+		return "", nil, err
+	}
+	draft, ok := ModuleCacheInstance.Get(key)
+	if !ok {
+		return "", nil, envelope.Errorf(envelope.CodeNotFound, "failed to get cache, load the module with viewModule first")
+	}
+	return key, draft, nil
+}
+
+// updateWorkspace applies update to the workspace of a conversation
+func updateWorkspace(sessionID string, update func(ws *Workspace)) {
+	workspaceMu.Lock()
+	defer workspaceMu.Unlock()
+
+	key := CacheKey(sessionID)
+	next := &Workspace{}
+	if ws, ok := WorkspaceInstance.Get(key); ok {
+		*next = *ws
+		next.Modules = append([]string(nil), ws.Modules...)
+		next.Entities = append([]string(nil), ws.Entities...)
+		next.OptionSets = append([]moduleconfig.OptionSet(nil), ws.OptionSets...)
+	}
+	update(next)
+	next.UpdatedAt = time.Now()
+	WorkspaceInstance.Set(key, next, workspaceExpiration())
+}
+
+// workspaceExpiration keeps workspaces at least as long as their drafts
+func workspaceExpiration() time.Duration {
+	if DraftExpiration <= 0 {
+		return 0
+	}
+	return max(DraftExpiration, WorkspaceExpiration)
+}
+
+// appendUnique appends value to list unless it is already present
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
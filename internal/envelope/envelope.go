@@ -0,0 +1,152 @@
+package envelope
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Result codes shared by all tools
+const (
+	CodeOK               = "ok"
+	CodeInvalidArguments = "invalid_arguments"
+	CodeNotFound         = "not_found"
+	CodeUnsupported      = "unsupported"
+	CodeInvalidConfig    = "invalid_config"
+	CodeUpstream         = "upstream_error"
+	CodeTimeout          = "timeout"
+	CodeCanceled         = "canceled"
+	CodeRejected         = "rejected"
+	CodeConflict         = "conflict"
+	CodePanic            = "panic"
+	CodeInternal         = "internal_error"
+)
+
+// Envelope is the JSON result every tool call returns to the model and the UI
+type Envelope struct {
+	Success  bool        `json:"success"`
+	Code     string      `json:"code"`
+	Message  string      `json:"message"`
+	Data     interface{} `json:"data"`
+	Warnings []string    `json:"warnings"`
+}
+
+// Error is a tool error carrying a result code and optional data for the failed result
+type Error struct {
+	Code string
+	Err  error
+	Data interface{}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Errorf creates a tool error with the given code; %w wraps the cause like fmt.Errorf
+func Errorf(code string, format string, args ...interface{}) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// ErrorWithData creates a tool error whose failed result carries data, e.g. a proposal to resolve it
+func ErrorWithData(code string, data interface{}, format string, args ...interface{}) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...), Data: data}
+}
+
+// PanicError is returned when a tool panicked while running
+type PanicError struct {
+	Tool  string
+	Value interface{}
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("tool '%s' panicked: %v", e.Tool, e.Value)
+}
+
+// CodeOf returns the result code that describes an error
+func CodeOf(err error) string {
+	var toolErr *Error
+	var panicErr *PanicError
+	switch {
+	case err == nil:
+		return CodeOK
+	case errors.As(err, &toolErr):
+		return toolErr.Code
+	case errors.As(err, &panicErr):
+		return CodePanic
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout
+	case errors.Is(err, context.Canceled):
+		return CodeCanceled
+	}
+	return CodeInternal
+}
+
+// OK returns a successful result with the given message, data and warnings
+func OK(message string, data interface{}, warnings ...string) (string, error) {
+	return marshal(&Envelope{
+		Success:  true,
+		Code:     CodeOK,
+		Message:  message,
+		Data:     data,
+		Warnings: append([]string{}, warnings...),
+	})
+}
+
+// Fail returns the failed result describing an error
+func Fail(err error) string {
+	var data interface{}
+	var toolErr *Error
+	if errors.As(err, &toolErr) {
+		data = toolErr.Data
+	}
+	result, marshalErr := marshal(&Envelope{
+		Success:  false,
+		Code:     CodeOf(err),
+		Message:  err.Error(),
+		Data:     data,
+		Warnings: []string{},
+	})
+	if marshalErr != nil {
+		return fmt.Sprintf(`{"success":false,"code":%q,"message":%q,"data":null,"warnings":[]}`, CodeInternal, err.Error())
+	}
+	return result
+}
+
+// Normalize wraps tool output that is not already an envelope into a successful result;
+// JSON output becomes the data of the result, any other text is kept as a string
+func Normalize(output string) string {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(output), &probe); err == nil {
+		_, hasSuccess := probe["success"]
+		_, hasCode := probe["code"]
+		_, hasMessage := probe["message"]
+		if hasSuccess && hasCode && hasMessage {
+			return output
+		}
+	}
+
+	var data interface{} = output
+	if json.Valid([]byte(output)) {
+		data = json.RawMessage(output)
+	}
+	result, err := OK("", data)
+	if err != nil {
+		return output
+	}
+	return result
+}
+
+// marshal formats an envelope the same way the tools always formatted their results
+func marshal(e *Envelope) (string, error) {
+	result, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to format response: %w", err)
+	}
+	return string(result), nil
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"coder/api"
+	"coder/internal/agent"
+)
+
+// approvalFinishReason is the finish reason of a response that waits for the user's approval
+const approvalFinishReason = "approval_required"
+
+// approvalMessage describes the pending tool calls and how to answer them
+func approvalMessage(pending *agent.PendingApproval) string {
+	names := make([]string, 0, len(pending.ToolCalls))
+	for _, tc := range pending.ToolCalls {
+		names = append(names, tc.Name)
+	}
+	return fmt.Sprintf("以下操作需要确认后才会执行：%s。请回复“确认”继续保存，或回复“拒绝”取消。", strings.Join(names, "、"))
+}
+
+// writeApprovalRequired writes the pending approval as the last chunk of a streaming response
+func writeApprovalRequired(sseWriter *SSEWriter, req *api.ChatRequest, modelID string, created int64, pending *agent.PendingApproval) {
+	finishReason := approvalFinishReason
+	sseWriter.WriteEvent(api.ChatStreamResponse{
+		ID:      req.ID,
+		Object:  "chat.completion.chunk",
+		Created: created,
+		Model:   modelID,
+		Choices: []api.ChatStreamResponseChoice{
+			{
+				Index: 0,
+				Delta: api.OpenAIMessage{
+					Role:    "assistant",
+					Content: approvalMessage(pending),
+				},
+				FinishReason: &finishReason,
+			},
+		},
+		PendingApproval: pending,
+	})
+	sseWriter.WriteDone()
+}
+
+// newApprovalResponse creates a non-streaming response for a run that waits for the user's approval
+func newApprovalResponse(req *api.ChatRequest, modelID string, pending *agent.PendingApproval) api.ChatResponse {
+	return api.ChatResponse{
+		ID:      req.ID,
+		Object:  "chat.completion",
+		Created: unixTimestamp(),
+		Model:   modelID,
+		Choices: []api.ChatResponseChoice{
+			{
+				Index: 0,
+				Message: api.OpenAIMessage{
+					Role:    "assistant",
+					Content: approvalMessage(pending),
+				},
+				FinishReason: approvalFinishReason,
+			},
+		},
+		PendingApproval: pending,
+	}
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"coder/internal/cache"
+	"coder/internal/envelope"
+	"coder/internal/moduleops"
+)
+
+// HandleListChanges lists the changes of a module draft of a conversation, the active one unless module_code is given
+func (h *Handler) HandleListChanges(c *gin.Context) {
+	key, err := cache.ResolveModuleKey(c.Param("id"), c.Query("module_code"))
+	if err != nil {
+		writeChangeError(c, err)
+		return
+	}
+	changes, err := cache.ListModuleChanges(key)
+	if err != nil {
+		writeChangeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, changes)
+}
+
+// HandleUndoChange undoes the last change of a module draft of a conversation, the active one unless module_code is given
+func (h *Handler) HandleUndoChange(c *gin.Context) {
+	key, err := cache.ResolveModuleKey(c.Param("id"), c.Query("module_code"))
+	if err != nil {
+		writeChangeError(c, err)
+		return
+	}
+	draft, revision, err := cache.UndoModuleChange(c.Request.Context(), key)
+	if err != nil {
+		writeChangeError(c, err)
+		return
+	}
+	writeChangeResult(c, draft, revision)
+}
+
+// HandleRedoChange reapplies the last undone change of a module draft of a conversation, the active one unless module_code is given
+func (h *Handler) HandleRedoChange(c *gin.Context) {
+	key, err := cache.ResolveModuleKey(c.Param("id"), c.Query("module_code"))
+	if err != nil {
+		writeChangeError(c, err)
+		return
+	}
+	draft, revision, err := cache.RedoModuleChange(c.Request.Context(), key)
+	if err != nil {
+		writeChangeError(c, err)
+		return
+	}
+	writeChangeResult(c, draft, revision)
+}
+
+// HandleDiffModule compares a module draft of a conversation, the active one unless module_code is given, with the configuration saved on the server
+func (h *Handler) HandleDiffModule(c *gin.Context) {
+	draft, diff, err := moduleops.DiffDraft(c.Param("id"), c.Query("module_code"))
+	if err != nil {
+		writeChangeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"moduleName": draft.ModuleName,
+		"moduleCode": draft.ModuleCode,
+		"diff":       diff,
+	})
+}
+
+// writeChangeResult writes the applied revision together with the resulting module configuration
+func writeChangeResult(c *gin.Context, draft *cache.ModuleCacheData, revision *cache.ModuleRevision) {
+	c.JSON(http.StatusOK, gin.H{
+		"change": cache.ModuleChange{
+			ID:        revision.ID,
+			Tool:      revision.Tool,
+			Summary:   revision.Summary,
+			CreatedAt: revision.CreatedAt,
+		},
+		"moduleName": draft.ModuleName,
+		"moduleCode": draft.ModuleCode,
+		"config":     json.RawMessage(draft.Cur),
+		"undoCount":  len(draft.Undo),
+		"redoCount":  len(draft.Redo),
+	})
+}
+
+// writeChangeError maps a draft history error to an HTTP error response
+func writeChangeError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	if envelope.CodeOf(err) == envelope.CodeNotFound {
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{"code": envelope.CodeOf(err), "error": err.Error()})
+}
@@ -4,10 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/cloudwego/eino/schema"
@@ -16,7 +16,6 @@ import (
 	"coder/api"
 	"coder/app"
 	"coder/internal/agent"
-	"coder/internal/cache"
 	"coder/internal/models"
 )
 
@@ -30,7 +29,7 @@ type Handler struct {
 func New(agent *agent.Agent) *Handler {
 	return &Handler{
 		agent:         agent,
-		conversations: models.NewConversationStore(),
+		conversations: models.NewConversationStore(app.Config.Chat.MaxHistoryLength),
 	}
 }
 
@@ -42,18 +41,11 @@ func (h *Handler) HandleChatCompletion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Model == "" {
-		req.Model = app.Config.OpenAI.ModelID
-	}
-
-	// 如果请求中没有指定temperature，使用配置中的默认值
-	if req.Temperature == 0 {
-		req.Temperature = 0.7 // 默认值
-	}
-
-	// 如果请求中没有指定max_tokens，使用配置中的默认值
-	if req.MaxTokens == 0 && app.Config.OpenAI.MaxTokens > 0 {
-		req.MaxTokens = app.Config.OpenAI.MaxTokens
+	// 未指定的 temperature 和 max_tokens 由所选模型的配置决定；请求未知模型时返回错误
+	modelID, err := h.agent.ResolveModel(req.Model)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	ctx := r.Context()
@@ -63,14 +55,14 @@ func (h *Handler) HandleChatCompletion(w http.ResponseWriter, r *http.Request) {
 
 	// Streaming response
 	if req.Stream {
-		h.handleStreamingResponse(ctx, w, &req, schemaMessages)
+		h.handleStreamingResponse(ctx, w, &req, modelID, schemaMessages)
 	} else {
-		h.handleNonStreamingResponse(ctx, w, &req, schemaMessages)
+		h.handleNonStreamingResponse(ctx, w, &req, modelID, schemaMessages)
 	}
 }
 
 // handleStreamingResponse handles streaming chat completion requests
-func (h *Handler) handleStreamingResponse(ctx context.Context, w http.ResponseWriter, req *api.ChatRequest, schemaMessages []*schema.Message) {
+func (h *Handler) handleStreamingResponse(ctx context.Context, w http.ResponseWriter, req *api.ChatRequest, modelID string, schemaMessages []*schema.Message) {
 	// Initialize SSE writer
 	sseWriter, err := NewSSEWriter(w)
 	if err != nil {
@@ -80,137 +72,157 @@ func (h *Handler) handleStreamingResponse(ctx context.Context, w http.ResponseWr
 
 	// Generate random ID for this chat completion
 	created := unixTimestamp()
-	// Process last user message and prepare chat history
-	userQuery, chatHistory := extractQueryAndHistory(schemaMessages)
+	// Prepare user query, chat history and cached module context
+	userQuery, chatHistory := h.prepareChat(req, schemaMessages)
 
-	// Stream response using Eino
+	// Forward tool progress to the client while the graph runs
+	ctx = agent.WithToolEventHandler(ctx, newToolEventHandler(sseWriter, req, modelID, created))
+	// 记录本轮的工具调用及结果，保存到对话历史
+	transcript := &agent.Transcript{}
+	ctx = agent.WithTranscript(ctx, transcript)
 
-	// 从缓存中获取模块名称 模块代码
-	// 获取cache
-	cacheKey := cache.CacheKey(req.ConversationID)
-	info, ok := cache.ModuleCacheInstance.Get(cacheKey)
-	if ok {
-		// 根据缓存数据类型添加不同的上下文信息
-		switch v := info.(type) {
-		case *cache.ModuleCacheData:
-			chatHistory = append(chatHistory, &schema.Message{
-				Role:    "user",
-				Content: "模块名称：" + v.ModuleName + "，模块代码：" + v.ModuleCode,
-			})
-			chatHistory = append(chatHistory, &schema.Message{
-				Role:    "user",
-				Content: fmt.Sprintf("这个是当前模块约束的配置，所有增加都需要在该配置里：%s", v.Support),
-			})
-			chatHistory = append(chatHistory, &schema.Message{
-				Role:    "user",
-				Content: fmt.Sprintf("这个是最新的配置，所有的调整都是基于该配置调整的：%s", v.Cur),
-			})
-		case *cache.EntityCacheData:
-			chatHistory = append(chatHistory, &schema.Message{
-				Role:    "user",
-				Content: "实体名称：" + v.EntityName,
-			})
-			chatHistory = append(chatHistory, &schema.Message{
-				Role:    "user",
-				Content: fmt.Sprintf("实体相关配置：%s", v.Config),
-			})
-		}
+	// Stream response using Eino
+	sr, usage, err := h.agent.Stream(ctx, req, app.Config.Chat.SystemPrompt, chatHistory, userQuery)
+	var approvalErr *agent.ApprovalRequiredError
+	if errors.As(err, &approvalErr) {
+		// 需要用户确认，运行已暂停
+		writeApprovalRequired(sseWriter, req, modelID, created, approvalErr.Pending)
+		h.recordTurn(req, userQuery, transcript, schema.AssistantMessage(approvalMessage(approvalErr.Pending), nil))
+		return
 	}
-
-	sr, err := h.agent.Stream(ctx, req, app.Config.Chat.SystemPrompt, chatHistory, userQuery)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		// 工具事件可能已写入 SSE 数据，错误也以 SSE 事件返回
+		sseWriter.WriteError(err)
+		sseWriter.WriteDone()
 		return
 	}
 	defer sr.Close()
 
-	content := ""
+	// 分离推理内容，未开启时不向客户端输出
+	var splitter reasoningSplitter
+	var answer strings.Builder
 	for {
 		chunk, err := sr.Recv()
 		if errors.Is(err, io.EOF) {
+			// Send content held back while waiting for a split <think> tag
+			content, reasoning := splitter.Flush()
+			writeContentDelta(sseWriter, req, modelID, created, content, reasoning)
+			answer.WriteString(content)
+			h.recordTurn(req, userQuery, transcript, schema.AssistantMessage(answer.String(), nil))
+
 			// Send final chunk with finish_reason
-			finishReason := "stop"
-			finalResponse := api.ChatStreamResponse{
-				ID:      req.ID,
-				Object:  "chat.completion.chunk",
-				Created: created,
-				Model:   req.Model,
-				Choices: []api.ChatStreamResponseChoice{
-					{
-						Index:        0,
-						Delta:        api.OpenAIMessage{},
-						FinishReason: &finishReason,
-					},
-				},
+			writeFinishReason(sseWriter, req, modelID, created, "stop")
+
+			// Write usage chunk when requested by the client; 模型未返回用量时不发送，避免返回全为 0 的用量
+			if req.StreamOptions != nil && req.StreamOptions.IncludeUsage && usage.Reported() {
+				total := usage.Total()
+				sseWriter.WriteEvent(api.ChatStreamResponse{
+					ID:      req.ID,
+					Object:  "chat.completion.chunk",
+					Created: created,
+					Model:   modelID,
+					Choices: []api.ChatStreamResponseChoice{},
+					Usage:   &total,
+				})
 			}
 
-			// Write final response and done marker
-			sseWriter.WriteEvent(finalResponse)
+			// Write done marker
 			sseWriter.WriteDone()
 			break
 		}
 		if err != nil {
 			log.Printf("Error receiving stream chunk: %v", err)
+			sseWriter.WriteError(err)
+			sseWriter.WriteDone()
 			break
 		}
 
-		content += chunk.Content
-
-		// Create OpenAI-compatible chunk response
-		response := api.ChatStreamResponse{
-			ID:      req.ID,
-			Object:  "chat.completion.chunk",
-			Created: created,
-			Model:   req.Model,
-			Choices: []api.ChatStreamResponseChoice{
-				{
-					Index: 0,
-					Delta: api.OpenAIMessage{
-						Role:    "assistant",
-						Content: chunk.Content,
-					},
-					FinishReason: nil,
+		content, reasoning := splitter.Split(chunk)
+		writeContentDelta(sseWriter, req, modelID, created, content, reasoning)
+		answer.WriteString(content)
+	}
+}
+
+// writeContentDelta writes a content delta, with reasoning only when the client asked for it
+func writeContentDelta(sseWriter *SSEWriter, req *api.ChatRequest, modelID string, created int64, content, reasoning string) {
+	if !req.IncludeReasoning {
+		reasoning = ""
+	}
+	if content == "" && reasoning == "" {
+		return
+	}
+
+	// Create OpenAI-compatible chunk response
+	response := api.ChatStreamResponse{
+		ID:      req.ID,
+		Object:  "chat.completion.chunk",
+		Created: created,
+		Model:   modelID,
+		Choices: []api.ChatStreamResponseChoice{
+			{
+				Index: 0,
+				Delta: api.OpenAIMessage{
+					Role:             "assistant",
+					Content:          content,
+					ReasoningContent: reasoning,
 				},
+				FinishReason: nil,
 			},
-		}
-
-		sseWriter.WriteEvent(response)
+		},
 	}
+
+	sseWriter.WriteEvent(response)
 }
 
 // handleNonStreamingResponse handles non-streaming chat completion requests
-func (h *Handler) handleNonStreamingResponse(ctx context.Context, w http.ResponseWriter, req *api.ChatRequest, schemaMessages []*schema.Message) {
-	// Process last user message and prepare chat history
-	userQuery, chatHistory := extractQueryAndHistory(schemaMessages)
+func (h *Handler) handleNonStreamingResponse(ctx context.Context, w http.ResponseWriter, req *api.ChatRequest, modelID string, schemaMessages []*schema.Message) {
+	// Prepare user query, chat history and cached module context
+	userQuery, chatHistory := h.prepareChat(req, schemaMessages)
+
+	// 记录本轮的工具调用及结果，保存到对话历史
+	transcript := &agent.Transcript{}
+	ctx = agent.WithTranscript(ctx, transcript)
 
 	// Generate response using Eino
-	result, err := h.agent.Generate(ctx, req, app.Config.Chat.SystemPrompt, chatHistory, userQuery)
+	result, usage, err := h.agent.Generate(ctx, req, app.Config.Chat.SystemPrompt, chatHistory, userQuery)
+	var approvalErr *agent.ApprovalRequiredError
+	if errors.As(err, &approvalErr) {
+		// 需要用户确认，运行已暂停
+		h.recordTurn(req, userQuery, transcript, schema.AssistantMessage(approvalMessage(approvalErr.Pending), nil))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(newApprovalResponse(req, modelID, approvalErr.Pending))
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// 将推理过程与最终回答分开
+	content, reasoning := splitReasoning(result)
+	if !req.IncludeReasoning {
+		reasoning = ""
+	}
+	h.recordTurn(req, userQuery, transcript, schema.AssistantMessage(content, nil))
+
 	// Create OpenAI-compatible response
 	response := api.ChatResponse{
 		ID:      req.ID,
 		Object:  "chat.completion",
 		Created: unixTimestamp(),
-		Model:   req.Model,
+		Model:   modelID,
 		Choices: []api.ChatResponseChoice{
 			{
 				Index: 0,
 				Message: api.OpenAIMessage{
-					Role:    "assistant",
-					Content: result.Content,
+					Role:             "assistant",
+					Content:          content,
+					ReasoningContent: reasoning,
 				},
 				FinishReason: "stop",
 			},
 		},
-		Usage: api.ChatResponseUsage{
-			PromptTokens:     0,
-			CompletionTokens: 0,
-			TotalTokens:      0,
-		},
+		Usage: usage.Total(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"sync"
+
+	"coder/api"
+	"coder/internal/agent"
+)
+
+// toolCallsFinishReason is the finish reason of a model turn that ends in tool calls
+const toolCallsFinishReason = "tool_calls"
+
+// newToolEventHandler forwards the tool events of a run to the SSE stream. Tool call deltas are sent as
+// OpenAI tool_calls deltas while the model streams them, and a model turn that ends in tool calls is closed
+// with finish_reason "tool_calls"; when the client sets tool_events the start, result and end events are
+// also sent as named SSE events so that a UI can render progress.
+func newToolEventHandler(sseWriter *SSEWriter, req *api.ChatRequest, modelID string, created int64) agent.ToolEventHandler {
+	var mu sync.Mutex
+	// 同一请求中的工具调用跨轮次连续编号，便于客户端按 index 归并
+	base, turnCalls := 0, 0
+
+	return func(event agent.ToolEvent) {
+		switch event.Type {
+		case agent.ToolEventDelta:
+			mu.Lock()
+			index := base + event.Index
+			turnCalls = max(turnCalls, event.Index+1)
+			mu.Unlock()
+
+			toolCall := api.OpenAIToolCall{
+				Index: index,
+				ID:    event.ToolCall.ID,
+				Function: api.OpenAIFunctionCall{
+					Name:      event.ToolCall.Name,
+					Arguments: event.ToolCall.Arguments,
+				},
+			}
+			if event.ToolCall.Name != "" {
+				toolCall.Type = "function"
+			}
+			sseWriter.WriteEvent(api.ChatStreamResponse{
+				ID:      req.ID,
+				Object:  "chat.completion.chunk",
+				Created: created,
+				Model:   modelID,
+				Choices: []api.ChatStreamResponseChoice{
+					{
+						Index: 0,
+						Delta: api.OpenAIMessage{
+							Role:      "assistant",
+							ToolCalls: []api.OpenAIToolCall{toolCall},
+						},
+						FinishReason: nil,
+					},
+				},
+			})
+			return
+
+		case agent.ToolEventCallsEnd:
+			mu.Lock()
+			base += turnCalls
+			turnCalls = 0
+			mu.Unlock()
+
+			writeFinishReason(sseWriter, req, modelID, created, toolCallsFinishReason)
+			return
+		}
+
+		if req.ToolEvents {
+			sseWriter.WriteNamedEvent(event.Type, event)
+		}
+	}
+}
+
+// writeFinishReason writes an empty delta that ends the current model turn with the given finish reason
+func writeFinishReason(sseWriter *SSEWriter, req *api.ChatRequest, modelID string, created int64, finishReason string) {
+	sseWriter.WriteEvent(api.ChatStreamResponse{
+		ID:      req.ID,
+		Object:  "chat.completion.chunk",
+		Created: created,
+		Model:   modelID,
+		Choices: []api.ChatStreamResponseChoice{
+			{
+				Index:        0,
+				Delta:        api.OpenAIMessage{},
+				FinishReason: &finishReason,
+			},
+		},
+	})
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/schema"
+
+	"coder/api"
+	"coder/app"
+	"coder/internal/agent"
+)
+
+// conversationHistory returns the history to use for a request. When the client sends earlier
+// turns it manages the history itself; otherwise the turns stored for the conversation are used.
+// Client system messages are kept in both cases.
+func (h *Handler) conversationHistory(req *api.ChatRequest, clientHistory []*schema.Message) []*schema.Message {
+	if req.ConversationID == "" {
+		return trimHistory(clientHistory)
+	}
+
+	var system []*schema.Message
+	for _, msg := range clientHistory {
+		if msg.Role != schema.System {
+			return trimHistory(clientHistory)
+		}
+		system = append(system, msg)
+	}
+
+	stored := h.conversations.GetOrCreate(req.ConversationID).GetMessages()
+	return append(system, trimHistory(stored)...)
+}
+
+// recordTurn stores the user message, the tool calls and results of the run and the answer of one turn
+func (h *Handler) recordTurn(req *api.ChatRequest, userQuery string, transcript *agent.Transcript, answer *schema.Message) {
+	if req.ConversationID == "" {
+		return
+	}
+
+	messages := append([]*schema.Message{schema.UserMessage(userQuery)}, transcript.Messages()...)
+	if answer != nil {
+		messages = append(messages, answer)
+	}
+	h.conversations.GetOrCreate(req.ConversationID).Append(messages...)
+}
+
+// trimHistory keeps the most recent messages allowed by chat.max_history_length and chat.max_history_tokens
+func trimHistory(history []*schema.Message) []*schema.Message {
+	return trimMessages(history, app.Config.Chat.MaxHistoryLength, app.Config.Chat.MaxHistoryTokens)
+}
+
+// trimMessages keeps the most recent messages within maxLength messages and maxTokens estimated tokens, 0 meaning
+// no limit. System messages are always kept, ahead of the others, and do not count towards the limits; the latest
+// user message and everything after it are kept even beyond the limits. The kept history never starts with tool
+// results whose tool call was trimmed away.
+func trimMessages(history []*schema.Message, maxLength, maxTokens int) []*schema.Message {
+	var system, messages []*schema.Message
+	for _, msg := range history {
+		if msg.Role == schema.System {
+			system = append(system, msg)
+		} else {
+			messages = append(messages, msg)
+		}
+	}
+
+	start := 0
+	if maxLength > 0 && len(messages) > maxLength {
+		start = len(messages) - maxLength
+	}
+	if maxTokens > 0 {
+		tokens := 0
+		for i := len(messages) - 1; i >= start; i-- {
+			tokens += estimateTokens(messages[i])
+			if tokens > maxTokens {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	// 最近一轮的用户消息及其后的工具调用和回答始终保留
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == schema.User {
+			start = min(start, i)
+			break
+		}
+	}
+	// 不能以工具结果开头，否则模型看不到对应的工具调用，上游会拒绝请求
+	for start < len(messages) && messages[start].Role == schema.Tool {
+		start++
+	}
+	return append(system, messages[start:]...)
+}
+
+// estimateTokens roughly estimates the tokens of a message: one per CJK character and one per four other characters
+func estimateTokens(msg *schema.Message) int {
+	text := msg.Content
+	for _, tc := range msg.ToolCalls {
+		text += tc.Function.Name + tc.Function.Arguments
+	}
+
+	cjk := 0
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			cjk++
+		}
+	}
+	other := utf8.RuneCountInString(text) - cjk
+	// 每条消息额外计入角色等固定开销
+	return cjk + (other+3)/4 + 4
+}
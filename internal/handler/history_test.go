@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// toolCallMessage is an assistant message calling the tool with the given id
+func toolCallMessage(id string) *schema.Message {
+	return schema.AssistantMessage("", []schema.ToolCall{{ID: id, Function: schema.FunctionCall{Name: "viewModule", Arguments: `{"module_code":"m"}`}}})
+}
+
+func TestTrimMessages(t *testing.T) {
+	system := schema.SystemMessage("system")
+	user1 := schema.UserMessage("first question")
+	answer1 := schema.AssistantMessage("first answer", nil)
+	user2 := schema.UserMessage("second question")
+	call := toolCallMessage("call-1")
+	result := schema.ToolMessage("result", "call-1")
+	calls := schema.AssistantMessage("", []schema.ToolCall{
+		{ID: "call-2", Function: schema.FunctionCall{Name: "addField"}},
+		{ID: "call-3", Function: schema.FunctionCall{Name: "addField"}},
+	})
+	result2 := schema.ToolMessage("result", "call-2")
+	result3 := schema.ToolMessage("result", "call-3")
+	answer2 := schema.AssistantMessage("second answer", nil)
+	long := schema.UserMessage(strings.Repeat("很长的问题", 100))
+
+	tests := []struct {
+		name      string
+		history   []*schema.Message
+		maxLength int
+		maxTokens int
+		want      []*schema.Message
+	}{
+		{
+			name:    "no limits",
+			history: []*schema.Message{system, user1, answer1, user2, call, result, answer2},
+			want:    []*schema.Message{system, user1, answer1, user2, call, result, answer2},
+		},
+		{
+			name:      "system message is kept beyond the length limit",
+			history:   []*schema.Message{system, user1, answer1, user2, answer2},
+			maxLength: 2,
+			want:      []*schema.Message{system, user2, answer2},
+		},
+		{
+			name:      "system message is kept beyond the token limit",
+			history:   []*schema.Message{system, user1, answer1, user2, answer2},
+			maxTokens: estimateTokens(user2) + estimateTokens(answer2),
+			want:      []*schema.Message{system, user2, answer2},
+		},
+		{
+			name:      "system messages move ahead of the others",
+			history:   []*schema.Message{user1, answer1, system, user2},
+			maxLength: 1,
+			want:      []*schema.Message{system, user2},
+		},
+		{
+			name:      "latest user turn is kept beyond the length limit",
+			history:   []*schema.Message{user1, answer1, user2, call, result, answer2},
+			maxLength: 1,
+			want:      []*schema.Message{user2, call, result, answer2},
+		},
+		{
+			name:      "latest user turn is kept beyond the token limit",
+			history:   []*schema.Message{system, user1, answer1, long},
+			maxTokens: 10,
+			want:      []*schema.Message{system, long},
+		},
+		{
+			name:      "tool result is not kept without its tool call",
+			history:   []*schema.Message{user1, call, result, answer1, user2, answer2},
+			maxLength: 4,
+			want:      []*schema.Message{answer1, user2, answer2},
+		},
+		{
+			name:      "tool results of parallel calls are dropped together",
+			history:   []*schema.Message{user1, calls, result2, result3, answer1, user2},
+			maxLength: 3,
+			want:      []*schema.Message{answer1, user2},
+		},
+		{
+			name:      "tool call is kept with its results",
+			history:   []*schema.Message{user1, answer1, user2, calls, result2, result3, answer2},
+			maxLength: 4,
+			want:      []*schema.Message{user2, calls, result2, result3, answer2},
+		},
+		{
+			name:      "latest turn with its tool calls is kept beyond the token limit",
+			history:   []*schema.Message{user1, call, result, answer2},
+			maxTokens: estimateTokens(result) + estimateTokens(answer2),
+			want:      []*schema.Message{user1, call, result, answer2},
+		},
+		{
+			name:      "history without user messages",
+			history:   []*schema.Message{call, result, answer1},
+			maxLength: 2,
+			want:      []*schema.Message{answer1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := trimMessages(tt.history, tt.maxLength, tt.maxTokens)
+			if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("trimMessages() = %v, want %v", contents(got), contents(tt.want))
+			}
+
+			// 保留的每条工具结果都必须有对应的工具调用
+			calls := make(map[string]bool)
+			for _, msg := range got {
+				for _, tc := range msg.ToolCalls {
+					calls[tc.ID] = true
+				}
+				if msg.Role == schema.Tool && !calls[msg.ToolCallID] {
+					t.Errorf("trimMessages() kept the result of tool call %s without the call", msg.ToolCallID)
+				}
+			}
+		})
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *schema.Message
+		want int
+	}{
+		{name: "empty", msg: schema.UserMessage(""), want: 4},
+		{name: "latin text", msg: schema.UserMessage("abcdefgh"), want: 2 + 4},
+		{name: "latin text rounds up", msg: schema.UserMessage("abcde"), want: 2 + 4},
+		{name: "cjk text", msg: schema.UserMessage("你好世界"), want: 4 + 4},
+		{name: "mixed text", msg: schema.UserMessage("新增 field"), want: 2 + 2 + 4},
+		{name: "tool calls", msg: schema.AssistantMessage("", []schema.ToolCall{{Function: schema.FunctionCall{Name: "view", Arguments: "{}"}}}), want: 2 + 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := estimateTokens(tt.msg); got != tt.want {
+				t.Errorf("estimateTokens() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// contents lists the roles and contents of messages for failure messages
+func contents(messages []*schema.Message) []string {
+	result := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		result = append(result, string(msg.Role)+":"+msg.Content)
+	}
+	return result
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/cloudwego/eino-ext/components/model/deepseek"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+// reasoningSplitter separates reasoning from answer content in streamed model output.
+// Reasoning comes either from the provider (DeepSeek reasoning_content) or from a leading
+// <think>...</think> block written by qwen style models; tags split across chunks are handled.
+// A <think> tag that does not open the content, leading whitespace aside, is part of the answer.
+type reasoningSplitter struct {
+	inThink bool
+	// 已进入回答部分，之后的标签都按正文处理
+	answering bool
+	pending   string
+	// 思考块结束后跳过紧随的换行
+	trimNewline bool
+}
+
+// Split returns the answer content and reasoning carried by one message chunk
+func (s *reasoningSplitter) Split(msg *schema.Message) (string, string) {
+	reasoning, _ := deepseek.GetReasoningContent(msg)
+	content, inline := s.feed(msg.Content)
+	return content, reasoning + inline
+}
+
+// Flush returns any text held back while waiting for a possibly split tag
+func (s *reasoningSplitter) Flush() (string, string) {
+	text := s.pending
+	s.pending = ""
+	if s.inThink {
+		return "", text
+	}
+	return text, ""
+}
+
+// feed consumes a chunk of content and returns the answer and reasoning parts found so far
+func (s *reasoningSplitter) feed(chunk string) (string, string) {
+	text := s.pending + chunk
+	s.pending = ""
+
+	var content, reasoning strings.Builder
+	for text != "" {
+		switch {
+		case s.answering:
+			s.write(&content, &reasoning, text)
+			text = ""
+
+		case s.inThink:
+			if i := strings.Index(text, thinkCloseTag); i >= 0 {
+				s.write(&content, &reasoning, text[:i])
+				text = text[i+len(thinkCloseTag):]
+				s.inThink = false
+				s.answering = true
+				s.trimNewline = true
+				continue
+			}
+			// 末尾可能是被截断的标签，先保留等待下一个分片
+			keep := partialSuffix(text, thinkCloseTag)
+			s.write(&content, &reasoning, text[:len(text)-keep])
+			s.pending = text[len(text)-keep:]
+			text = ""
+
+		default:
+			// 只有位于开头（允许前导空白）的 <think> 才是推理内容
+			trimmed := strings.TrimLeft(text, " \t\r\n")
+			switch {
+			case strings.HasPrefix(trimmed, thinkOpenTag):
+				text = trimmed[len(thinkOpenTag):]
+				s.inThink = true
+			case strings.HasPrefix(thinkOpenTag, trimmed):
+				// 仍是空白或被截断的开始标签，等待下一个分片
+				s.pending = text
+				text = ""
+			default:
+				s.answering = true
+			}
+		}
+	}
+	return content.String(), reasoning.String()
+}
+
+// write appends text to the reasoning or the content depending on the current block
+func (s *reasoningSplitter) write(content, reasoning *strings.Builder, text string) {
+	if s.inThink {
+		reasoning.WriteString(text)
+		return
+	}
+	if s.trimNewline {
+		text = strings.TrimLeft(text, "\r\n")
+		s.trimNewline = text == ""
+	}
+	content.WriteString(text)
+}
+
+// partialSuffix returns the length of the longest suffix of text that is a proper prefix of tag
+func partialSuffix(text, tag string) int {
+	for n := min(len(tag)-1, len(text)); n > 0; n-- {
+		if strings.HasSuffix(text, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// splitReasoning separates reasoning from answer content in a complete message
+func splitReasoning(msg *schema.Message) (string, string) {
+	var s reasoningSplitter
+	content, reasoning := s.Split(msg)
+	restContent, restReasoning := s.Flush()
+	return content + restContent, reasoning + restReasoning
+}
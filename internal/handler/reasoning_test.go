@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// splitChunks feeds the chunks through a reasoningSplitter and returns the whole content and reasoning
+func splitChunks(chunks []string) (string, string) {
+	var s reasoningSplitter
+	var content, reasoning strings.Builder
+	for _, chunk := range chunks {
+		c, r := s.Split(&schema.Message{Content: chunk})
+		content.WriteString(c)
+		reasoning.WriteString(r)
+	}
+	c, r := s.Flush()
+	content.WriteString(c)
+	reasoning.WriteString(r)
+	return content.String(), reasoning.String()
+}
+
+func TestReasoningSplitter(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		wantContent   string
+		wantReasoning string
+	}{
+		{
+			name:        "no reasoning",
+			input:       "你好，有什么可以帮你",
+			wantContent: "你好，有什么可以帮你",
+		},
+		{
+			name:          "leading think block",
+			input:         "<think>先查看模块</think>\n模块已打开",
+			wantContent:   "模块已打开",
+			wantReasoning: "先查看模块",
+		},
+		{
+			name:          "think block after whitespace",
+			input:         " \n<think>x</think>answer",
+			wantContent:   "answer",
+			wantReasoning: "x",
+		},
+		{
+			name:        "tag mentioned inside the answer",
+			input:       "用 <think> 和 </think> 包裹推理内容",
+			wantContent: "用 <think> 和 </think> 包裹推理内容",
+		},
+		{
+			name:          "second think block is answer",
+			input:         "<think>a</think>b<think>c</think>",
+			wantContent:   "b<think>c</think>",
+			wantReasoning: "a",
+		},
+		{
+			name:          "unterminated think block",
+			input:         "<think>还在思考",
+			wantReasoning: "还在思考",
+		},
+		{
+			name:        "prefix of the tag only",
+			input:       "<thi",
+			wantContent: "<thi",
+		},
+		{
+			name:        "close tag without open tag",
+			input:       "answer</think>",
+			wantContent: "answer</think>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 在每个字节位置切分成两个分片
+			for i := 0; i <= len(tt.input); i++ {
+				content, reasoning := splitChunks([]string{tt.input[:i], tt.input[i:]})
+				if content != tt.wantContent || reasoning != tt.wantReasoning {
+					t.Fatalf("split at %d: got content %q, reasoning %q, want %q, %q", i, content, reasoning, tt.wantContent, tt.wantReasoning)
+				}
+			}
+
+			// 每个字节一个分片
+			chunks := make([]string, 0, len(tt.input))
+			for i := 0; i < len(tt.input); i++ {
+				chunks = append(chunks, tt.input[i:i+1])
+			}
+			content, reasoning := splitChunks(chunks)
+			if content != tt.wantContent || reasoning != tt.wantReasoning {
+				t.Errorf("byte chunks: got content %q, reasoning %q, want %q, %q", content, reasoning, tt.wantContent, tt.wantReasoning)
+			}
+		})
+	}
+}
@@ -4,12 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 )
 
 // SSEWriter helps manage Server-Sent Events (SSE) responses
 type SSEWriter struct {
 	w       http.ResponseWriter
 	flusher http.Flusher
+	mu      sync.Mutex
 }
 
 // NewSSEWriter creates a new SSE writer
@@ -40,6 +42,8 @@ func (s *SSEWriter) WriteEvent(data interface{}) error {
 		return err
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	_, err = fmt.Fprintf(s.w, "data: %s\n\n", jsonData)
 	if err != nil {
 		return err
@@ -49,8 +53,39 @@ func (s *SSEWriter) WriteEvent(data interface{}) error {
 	return nil
 }
 
+// WriteNamedEvent writes a single SSE event with an event type
+func (s *SSEWriter) WriteNamedEvent(event string, data interface{}) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, jsonData)
+	if err != nil {
+		return err
+	}
+
+	s.flusher.Flush()
+	return nil
+}
+
+// WriteError writes an error as an SSE error event in the OpenAI error format, for errors that happen after
+// the stream has started and can no longer be reported with an HTTP status
+func (s *SSEWriter) WriteError(err error) error {
+	return s.WriteNamedEvent("error", map[string]interface{}{
+		"error": map[string]string{
+			"message": err.Error(),
+			"type":    "server_error",
+		},
+	})
+}
+
 // WriteRaw writes raw data as an SSE event
 func (s *SSEWriter) WriteRaw(data string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	_, err := fmt.Fprintf(s.w, "data: %s\n\n", data)
 	if err != nil {
 		return err
@@ -62,6 +97,8 @@ func (s *SSEWriter) WriteRaw(data string) error {
 
 // WriteDone writes the SSE [DONE] marker
 func (s *SSEWriter) WriteDone() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	_, err := fmt.Fprint(s.w, "data: [DONE]\n\n")
 	if err != nil {
 		return err
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"coder/api"
+	"coder/internal/cache"
+)
+
+// prepareChat builds the user query and chat history for a request, shared by the streaming and non-streaming paths
+func (h *Handler) prepareChat(req *api.ChatRequest, schemaMessages []*schema.Message) (string, []*schema.Message) {
+	// Process last user message and prepare chat history
+	userQuery, chatHistory := extractQueryAndHistory(schemaMessages)
+
+	// 使用服务端保存的对话历史，并按配置裁剪
+	chatHistory = h.conversationHistory(req, chatHistory)
+
+	// 从缓存中获取当前对话打开的模块和实体
+	return userQuery, appendCacheContext(req.ConversationID, chatHistory)
+}
+
+// appendCacheContext appends the module and entity drafts open in the conversation to the chat history
+func appendCacheContext(conversationID string, chatHistory []*schema.Message) []*schema.Message {
+	ws := cache.GetWorkspace(conversationID)
+
+	// 打开了多个草稿时说明当前模块和实体，以及如何操作其他草稿
+	if len(ws.Modules)+len(ws.Entities) > 1 {
+		chatHistory = append(chatHistory, schema.UserMessage(fmt.Sprintf(
+			"当前对话已打开的模块：%s，当前模块：%s；已打开的实体：%s，当前实体：%s。操作其他模块时请传入 module_code，或先用 switchModule 切换当前模块",
+			strings.Join(ws.Modules, "、"), ws.ActiveModule, strings.Join(ws.Entities, "、"), ws.ActiveEntity)))
+	}
+
+	for _, code := range ws.Modules {
+		v, ok := cache.ModuleCacheInstance.Get(cache.ModuleKey(conversationID, code))
+		if !ok {
+			continue
+		}
+		if code != ws.ActiveModule {
+			chatHistory = append(chatHistory,
+				schema.UserMessage(fmt.Sprintf("模块名称：%s，模块代码：%s（非当前模块），最新的配置：%s", v.ModuleName, v.ModuleCode, v.Cur)),
+			)
+			continue
+		}
+		chatHistory = append(chatHistory,
+			schema.UserMessage("模块名称："+v.ModuleName+"，模块代码："+v.ModuleCode),
+			schema.UserMessage(fmt.Sprintf("这个是当前模块约束的配置，所有增加都需要在该配置里：%s", v.Support)),
+			schema.UserMessage(fmt.Sprintf("这个是最新的配置，所有的调整都是基于该配置调整的：%s", v.Cur)),
+		)
+	}
+
+	for _, name := range ws.Entities {
+		v, ok := cache.EntityCacheInstance.Get(cache.EntityKey(conversationID, name))
+		if !ok {
+			continue
+		}
+		chatHistory = append(chatHistory,
+			schema.UserMessage("实体名称："+v.EntityName),
+			schema.UserMessage(fmt.Sprintf("实体相关配置：%s", v.Config)),
+		)
+	}
+
+	// 选项集供绑定字段时选择
+	if len(ws.OptionSets) > 0 {
+		optionSets, _ := json.Marshal(ws.OptionSets)
+		chatHistory = append(chatHistory, schema.UserMessage(fmt.Sprintf("当前对话的选项集（可用 bindOptionSet 绑定到字段）：%s", optionSets)))
+	}
+
+	return chatHistory
+}
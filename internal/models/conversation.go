@@ -2,55 +2,80 @@ package models
 
 import (
 	"sync"
+	"time"
 
 	"github.com/cloudwego/eino/schema"
+
+	"coder/internal/cache"
 )
 
+// ConversationExpiration is how long a conversation is kept after its last use
+const ConversationExpiration = 24 * time.Hour
+
 // Conversation stores the messages of a conversation
 type Conversation struct {
-	mu       sync.Mutex
-	ID       string
-	Messages []*schema.Message
+	mu          sync.Mutex
+	ID          string
+	Messages    []*schema.Message
+	maxMessages int
 }
 
-// Append adds a message to the conversation
-func (c *Conversation) Append(msg *schema.Message) {
+// Append adds messages to the conversation, dropping the oldest beyond the message limit
+func (c *Conversation) Append(msgs ...*schema.Message) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.Messages = append(c.Messages, msg)
+	c.Messages = append(c.Messages, msgs...)
+
+	if c.maxMessages <= 0 || len(c.Messages) <= c.maxMessages {
+		return
+	}
+	start := len(c.Messages) - c.maxMessages
+	// 不能以工具结果开头，否则模型看不到对应的工具调用
+	for start < len(c.Messages) && c.Messages[start].Role == schema.Tool {
+		start++
+	}
+	// 复制到新切片，释放被截掉的消息
+	c.Messages = append([]*schema.Message(nil), c.Messages[start:]...)
 }
 
-// GetMessages returns all messages in the conversation
+// GetMessages returns a copy of all messages in the conversation
 func (c *Conversation) GetMessages() []*schema.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	return c.Messages
+	return append([]*schema.Message(nil), c.Messages...)
 }
 
-// ConversationStore stores all conversations
+// ConversationStore stores the conversations used in the last ConversationExpiration
 type ConversationStore struct {
 	mu            sync.Mutex
-	conversations map[string]*Conversation
+	conversations *cache.Cache[*Conversation]
+	maxMessages   int
 }
 
-// NewConversationStore creates a new conversation store
-func NewConversationStore() *ConversationStore {
+// NewConversationStore creates a new conversation store keeping at most maxMessages messages
+// per conversation; 0 keeps all of them
+func NewConversationStore(maxMessages int) *ConversationStore {
 	return &ConversationStore{
-		conversations: make(map[string]*Conversation),
+		conversations: cache.New[*Conversation](),
+		maxMessages:   maxMessages,
 	}
 }
 
-// GetOrCreate gets or creates a conversation
+// GetOrCreate gets or creates a conversation and renews its expiration
 func (s *ConversationStore) GetOrCreate(id string) *Conversation {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, ok := s.conversations[id]; !ok {
-		s.conversations[id] = &Conversation{
-			ID:       id,
-			Messages: make([]*schema.Message, 0),
+	conversation, ok := s.conversations.Get(id)
+	if !ok {
+		conversation = &Conversation{
+			ID:          id,
+			Messages:    make([]*schema.Message, 0),
+			maxMessages: s.maxMessages,
 		}
 	}
+	// 每次使用都续期，长时间不用的会话由缓存清理
+	s.conversations.Set(id, conversation, ConversationExpiration)
 
-	return s.conversations[id]
+	return conversation
 }
@@ -0,0 +1,356 @@
+package moduleconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"coder/internal/envelope"
+)
+
+// Config is the dynamicForm configuration of a module; keys it does not model are kept in Extra
+type Config struct {
+	PageName       *PageName `json:"pageName"`
+	CreateFields   []Field   `json:"createFields"`
+	UpdateFields   []Field   `json:"updateFields"`
+	TableFields    []Field   `json:"tableFields"`
+	ViewConfig     []Field   `json:"viewConfig"`
+	SearchFields   []Field   `json:"searchFields"`
+	TableActions   []Action  `json:"tableActions"`
+	TableOperation []Action  `json:"tableOperation"`
+	ListAPI        string    `json:"listAPI,omitempty"`
+	CreateAPI      string    `json:"createAPI,omitempty"`
+	GetAPI         string    `json:"getAPI,omitempty"`
+	UpdateAPI      string    `json:"updateAPI,omitempty"`
+	DeleteAPI      string    `json:"deleteAPI,omitempty"`
+	Layout         *Layout   `json:"layout"`
+	Columns        *int      `json:"columns"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Field is a field of a form, table, detail or search section
+type Field struct {
+	Field   string                   `json:"field"`
+	Label   string                   `json:"label,omitempty"`
+	Type    string                   `json:"type,omitempty"`
+	Props   map[string]interface{}   `json:"props,omitempty"`
+	Options interface{}              `json:"options,omitempty"`
+	Rules   []map[string]interface{} `json:"rules,omitempty"`
+
+	OptionSet string `json:"optionSet,omitempty"` // Name of the option set the options come from
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Action is a table action or a row operation
+type Action struct {
+	Title   string                 `json:"title"`
+	Type    string                 `json:"type,omitempty"`
+	Options map[string]interface{} `json:"options,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// PageName holds the titles of the module pages
+type PageName struct {
+	Table string `json:"table,omitempty"`
+	New   string `json:"new,omitempty"`
+	Edit  string `json:"edit,omitempty"`
+	Name  string `json:"name,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Layout holds the page layouts of the module
+type Layout struct {
+	Table string `json:"table,omitempty"`
+	Form  string `json:"form,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// APIKeys are the configuration keys of the module APIs
+var APIKeys = []string{"listAPI", "createAPI", "getAPI", "updateAPI", "deleteAPI"}
+
+// ActionSections are the configuration keys of the action lists
+var ActionSections = []string{"tableActions", "tableOperation"}
+
+// ListSection is a list section of a module configuration and the property identifying its items
+type ListSection struct {
+	Name string
+	Key  string
+}
+
+// ListSections returns the list sections of a module configuration: the sections of Ranges and ActionSections
+func ListSections() []ListSection {
+	sections := make([]ListSection, 0, len(Ranges)+len(ActionSections))
+	for _, r := range Ranges {
+		sections = append(sections, ListSection{Name: r.Section, Key: "field"})
+	}
+	for _, section := range ActionSections {
+		sections = append(sections, ListSection{Name: section, Key: "title"})
+	}
+	return sections
+}
+
+// Parse decodes a module configuration; an empty configuration decodes to an empty one
+func Parse(config string) (*Config, error) {
+	cfg := &Config{}
+	if strings.TrimSpace(config) == "" {
+		return cfg, nil
+	}
+	if err := json.Unmarshal([]byte(config), cfg); err != nil {
+		return nil, envelope.Errorf(envelope.CodeInvalidConfig, "malformed module config: %s", describeJSONError(err))
+	}
+	return cfg, nil
+}
+
+// JSON encodes the configuration
+func (c *Config) JSON() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal module config: %w", err)
+	}
+	return string(data), nil
+}
+
+// Clone returns a deep copy of the configuration
+func (c *Config) Clone() (*Config, error) {
+	data, err := c.JSON()
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Fields returns the field list stored under a configuration key such as createFields, or nil for other keys
+func (c *Config) Fields(section string) *[]Field {
+	switch section {
+	case "createFields":
+		return &c.CreateFields
+	case "updateFields":
+		return &c.UpdateFields
+	case "tableFields":
+		return &c.TableFields
+	case "viewConfig":
+		return &c.ViewConfig
+	case "searchFields":
+		return &c.SearchFields
+	}
+	return nil
+}
+
+// Actions returns the action list stored under tableActions or tableOperation, or nil for other keys
+func (c *Config) Actions(section string) *[]Action {
+	switch section {
+	case "tableActions":
+		return &c.TableActions
+	case "tableOperation":
+		return &c.TableOperation
+	}
+	return nil
+}
+
+// API returns the URL stored under one of APIKeys, or nil for other keys
+func (c *Config) API(key string) *string {
+	switch key {
+	case "listAPI":
+		return &c.ListAPI
+	case "createAPI":
+		return &c.CreateAPI
+	case "getAPI":
+		return &c.GetAPI
+	case "updateAPI":
+		return &c.UpdateAPI
+	case "deleteAPI":
+		return &c.DeleteAPI
+	}
+	return nil
+}
+
+// FieldIndex returns the position of the field with the given name, or -1
+func FieldIndex(fields []Field, name string) int {
+	for index, field := range fields {
+		if field.Field == name {
+			return index
+		}
+	}
+	return -1
+}
+
+// ActionIndex returns the position of the action with the given title, or -1
+func ActionIndex(actions []Action, title string) int {
+	for index, action := range actions {
+		if action.Title == title {
+			return index
+		}
+	}
+	return -1
+}
+
+type configJSON Config
+type fieldJSON Field
+type actionJSON Action
+type pageNameJSON PageName
+type layoutJSON Layout
+
+func (c *Config) UnmarshalJSON(data []byte) error {
+	var v configJSON
+	extra, err := decodeWithExtra(data, &v)
+	if err != nil {
+		return err
+	}
+	*c = Config(v)
+	c.Extra = extra
+	return nil
+}
+
+func (c Config) MarshalJSON() ([]byte, error) {
+	return encodeWithExtra(configJSON(c), c.Extra)
+}
+
+func (f *Field) UnmarshalJSON(data []byte) error {
+	var v fieldJSON
+	extra, err := decodeWithExtra(data, &v)
+	if err != nil {
+		return err
+	}
+	*f = Field(v)
+	f.Extra = extra
+	return nil
+}
+
+func (f Field) MarshalJSON() ([]byte, error) {
+	return encodeWithExtra(fieldJSON(f), f.Extra)
+}
+
+func (a *Action) UnmarshalJSON(data []byte) error {
+	var v actionJSON
+	extra, err := decodeWithExtra(data, &v)
+	if err != nil {
+		return err
+	}
+	*a = Action(v)
+	a.Extra = extra
+	return nil
+}
+
+func (a Action) MarshalJSON() ([]byte, error) {
+	return encodeWithExtra(actionJSON(a), a.Extra)
+}
+
+func (p *PageName) UnmarshalJSON(data []byte) error {
+	var v pageNameJSON
+	extra, err := decodeWithExtra(data, &v)
+	if err != nil {
+		return err
+	}
+	*p = PageName(v)
+	p.Extra = extra
+	return nil
+}
+
+func (p PageName) MarshalJSON() ([]byte, error) {
+	return encodeWithExtra(pageNameJSON(p), p.Extra)
+}
+
+func (l *Layout) UnmarshalJSON(data []byte) error {
+	var v layoutJSON
+	extra, err := decodeWithExtra(data, &v)
+	if err != nil {
+		return err
+	}
+	*l = Layout(v)
+	l.Extra = extra
+	return nil
+}
+
+func (l Layout) MarshalJSON() ([]byte, error) {
+	return encodeWithExtra(layoutJSON(l), l.Extra)
+}
+
+// decodeWithExtra decodes an object into v and returns the keys v does not declare
+func decodeWithExtra(data []byte, v interface{}) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	for _, key := range jsonKeys(reflect.TypeOf(v).Elem()) {
+		delete(all, key)
+	}
+	if len(all) == 0 {
+		return nil, nil
+	}
+	return all, nil
+}
+
+// encodeWithExtra encodes v with the extra keys added; null values are left out so absent keys stay absent
+func encodeWithExtra(v interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	for key, value := range all {
+		if string(value) == "null" {
+			delete(all, key)
+		}
+	}
+	for key, value := range extra {
+		if _, ok := all[key]; !ok {
+			all[key] = value
+		}
+	}
+	return json.Marshal(all)
+}
+
+// jsonKeys returns the JSON keys declared by the fields of a struct type
+func jsonKeys(t reflect.Type) []string {
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// describeJSONError names the key and the expected type of a JSON decoding error
+func describeJSONError(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return fmt.Sprintf("%s must be %s but is %s", typeErr.Field, typeName(typeErr.Type), typeErr.Value)
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Sprintf("invalid JSON at offset %d: %v", syntaxErr.Offset, err)
+	}
+	return err.Error()
+}
+
+// typeName describes a Go type in JSON terms
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		return "a number"
+	case reflect.Slice:
+		return "a list"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Ptr:
+		return typeName(t.Elem())
+	}
+	return t.String()
+}
@@ -0,0 +1,180 @@
+package moduleconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"coder/internal/envelope"
+)
+
+// Edit operations and targets
+const (
+	OpAdd    = "add"
+	OpEdit   = "edit"
+	OpDelete = "delete"
+	OpMove   = "move"
+
+	TargetField     = "field"
+	TargetAction    = "action"
+	TargetOperation = "operation"
+	TargetAPI       = "api"
+)
+
+// Edit is one change to a module configuration
+type Edit struct {
+	Op     string `json:"op"`     // add, edit, delete or move
+	Target string `json:"target"` // field, action, operation or api
+
+	// 字段：add/edit 使用 fields，delete 使用 names，move 使用 field 加位置或 order
+	RangeCode string   `json:"range_code,omitempty"`
+	Fields    []Field  `json:"fields,omitempty"`
+	Names     []string `json:"names,omitempty"`
+	Field     string   `json:"field,omitempty"`
+	Order     []string `json:"order,omitempty"`
+	MoveTarget
+
+	// 按钮和行操作：按 title 定位，edit 时 old_title 为原标题
+	Title    string                 `json:"title,omitempty"`
+	OldTitle string                 `json:"old_title,omitempty"`
+	Type     string                 `json:"type,omitempty"` // Action type, or API key such as listAPI
+	Options  map[string]interface{} `json:"options,omitempty"`
+
+	URL string `json:"url,omitempty"`
+}
+
+// Apply applies the edit to cfg and describes it; unlike the single edit tools it fails on fields that are
+// unsupported, already present or missing instead of skipping them, with the field report as error data
+func Apply(cfg, support *Config, edit Edit) (string, error) {
+	switch edit.Target {
+	case TargetField:
+		return applyField(cfg, support, edit)
+	case TargetAction, TargetOperation:
+		return applyAction(cfg, edit)
+	case TargetAPI:
+		return applyAPI(cfg, edit)
+	}
+	return "", envelope.Errorf(envelope.CodeInvalidArguments, "unknown target '%s', must be one of: field, action, operation, api", edit.Target)
+}
+
+func applyField(cfg, support *Config, edit Edit) (string, error) {
+	r, err := ResolveRange(edit.RangeCode)
+	if err != nil {
+		return "", err
+	}
+	section := r.Section
+	fields := cfg.Fields(section)
+
+	switch edit.Op {
+	case OpAdd, OpEdit, OpDelete:
+		var report *FieldReport
+		switch {
+		case edit.Op == OpDelete && len(edit.Names) > 0:
+			report = DeleteFields(cfg, r, edit.Names)
+		case edit.Op == OpDelete:
+			return "", envelope.Errorf(envelope.CodeInvalidArguments, "names cannot be empty")
+		case len(edit.Fields) == 0:
+			return "", envelope.Errorf(envelope.CodeInvalidArguments, "fields cannot be empty")
+		case edit.Op == OpAdd:
+			report = AddFields(cfg, support, r, edit.Fields)
+		default:
+			report = EditFields(cfg, r, edit.Fields)
+		}
+		// 批量修改要求每个字段都生效
+		switch {
+		case len(report.Unsupported) > 0:
+			return "", envelope.ErrorWithData(envelope.CodeUnsupported, report, "%s: %s", section, report.Summary())
+		case len(report.Duplicates) > 0:
+			return "", envelope.ErrorWithData(envelope.CodeInvalidArguments, report, "%s: %s", section, report.Summary())
+		case len(report.Missing) > 0:
+			return "", envelope.ErrorWithData(envelope.CodeNotFound, report, "%s: %s", section, report.Summary())
+		}
+		return fmt.Sprintf("%s: %s", section, report.Summary()), nil
+
+	case OpMove:
+		var moved []Field
+		switch {
+		case len(edit.Order) > 0 && edit.Field == "":
+			moved, err = ReorderFields(*fields, edit.Order)
+		case len(edit.Order) == 0 && edit.Field != "":
+			moved, err = MoveField(*fields, edit.Field, edit.MoveTarget)
+		default:
+			err = envelope.Errorf(envelope.CodeInvalidArguments, "either field or order must be given")
+		}
+		if err != nil {
+			return "", err
+		}
+		*fields = moved
+		return fmt.Sprintf("reordered %s to %v", section, FieldNames(moved)), nil
+	}
+	return "", unknownOp(edit, OpAdd, OpEdit, OpDelete, OpMove)
+}
+
+func applyAction(cfg *Config, edit Edit) (string, error) {
+	section := "tableActions"
+	if edit.Target == TargetOperation {
+		section = "tableOperation"
+	}
+	actions := cfg.Actions(section)
+	if edit.Title == "" {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "title cannot be empty")
+	}
+
+	switch edit.Op {
+	case OpAdd, OpEdit:
+		lookup := edit.Title
+		if edit.Op == OpEdit && edit.OldTitle != "" {
+			lookup = edit.OldTitle
+		}
+		index := ActionIndex(*actions, lookup)
+		if edit.Op == OpAdd && index >= 0 {
+			return "", envelope.Errorf(envelope.CodeInvalidArguments, "%s '%s' already exists, edit it instead", edit.Target, edit.Title)
+		}
+		if edit.Op == OpEdit && index < 0 {
+			return "", envelope.Errorf(envelope.CodeNotFound, "%s '%s' does not exist in %s", edit.Target, lookup, section)
+		}
+		action := Action{Title: edit.Title, Type: edit.Type, Options: edit.Options}
+		if index >= 0 {
+			(*actions)[index] = action
+			return fmt.Sprintf("edited %s '%s' in %s", edit.Target, edit.Title, section), nil
+		}
+		*actions = append(*actions, action)
+		return fmt.Sprintf("added %s '%s' to %s", edit.Target, edit.Title, section), nil
+
+	case OpDelete:
+		index := ActionIndex(*actions, edit.Title)
+		if index < 0 {
+			return "", envelope.Errorf(envelope.CodeNotFound, "%s '%s' does not exist in %s", edit.Target, edit.Title, section)
+		}
+		*actions = append((*actions)[:index], (*actions)[index+1:]...)
+		return fmt.Sprintf("deleted %s '%s' from %s", edit.Target, edit.Title, section), nil
+	}
+	return "", unknownOp(edit, OpAdd, OpEdit, OpDelete)
+}
+
+func applyAPI(cfg *Config, edit Edit) (string, error) {
+	url := cfg.API(edit.Type)
+	if url == nil {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "invalid type: %s. Must be one of: %s", edit.Type, strings.Join(APIKeys, ", "))
+	}
+
+	switch edit.Op {
+	case OpAdd, OpEdit:
+		if edit.URL == "" {
+			return "", envelope.Errorf(envelope.CodeInvalidArguments, "url cannot be empty")
+		}
+		*url = edit.URL
+		return fmt.Sprintf("set %s to %s", edit.Type, edit.URL), nil
+
+	case OpDelete:
+		if *url == "" {
+			return "", envelope.Errorf(envelope.CodeNotFound, "%s is not set", edit.Type)
+		}
+		*url = ""
+		return fmt.Sprintf("deleted %s", edit.Type), nil
+	}
+	return "", unknownOp(edit, OpAdd, OpEdit, OpDelete)
+}
+
+func unknownOp(edit Edit, ops ...string) error {
+	return envelope.Errorf(envelope.CodeInvalidArguments, "unknown op '%s' for target %s, must be one of: %s", edit.Op, edit.Target, strings.Join(ops, ", "))
+}
@@ -0,0 +1,225 @@
+package moduleconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldReport tells which fields a field edit changed and which it skipped
+type FieldReport struct {
+	Added       []string       `json:"added,omitempty"`
+	Updated     []string       `json:"updated,omitempty"`
+	Deleted     []string       `json:"deleted,omitempty"`
+	Duplicates  []string       `json:"duplicates,omitempty"`  // Already in the range, skipped by add
+	Missing     []SkippedField `json:"missing,omitempty"`     // Not in the range, skipped by edit and delete
+	Unsupported []SkippedField `json:"unsupported,omitempty"` // Not supported by the range, rejected by add
+}
+
+// SkippedField is a field an edit could not apply, with the fields that were probably meant
+type SkippedField struct {
+	Field       string       `json:"field"`
+	Label       string       `json:"label,omitempty"`
+	Suggestions []Suggestion `json:"suggestions,omitempty"`
+}
+
+// Suggestion is a field whose code or label is close to a field that could not be applied
+type Suggestion struct {
+	Field string `json:"field"`
+	Label string `json:"label,omitempty"`
+}
+
+// Changed reports whether the edit changed any field
+func (r *FieldReport) Changed() bool {
+	return len(r.Added)+len(r.Updated)+len(r.Deleted) > 0
+}
+
+// Skipped reports whether the edit skipped or rejected any field
+func (r *FieldReport) Skipped() bool {
+	return len(r.Duplicates)+len(r.Missing)+len(r.Unsupported) > 0
+}
+
+// Summary describes the report in one line
+func (r *FieldReport) Summary() string {
+	var parts []string
+	for _, part := range []struct {
+		name   string
+		fields []string
+	}{{"added", r.Added}, {"updated", r.Updated}, {"deleted", r.Deleted}, {"skipped as already present", r.Duplicates}} {
+		if len(part.fields) > 0 {
+			parts = append(parts, fmt.Sprintf("%s %v", part.name, part.fields))
+		}
+	}
+	if len(r.Missing) > 0 {
+		parts = append(parts, "skipped as not present ["+describeSkipped(r.Missing)+"]")
+	}
+	if len(r.Unsupported) > 0 {
+		parts = append(parts, "rejected as unsupported ["+describeSkipped(r.Unsupported)+"]")
+	}
+	if len(parts) == 0 {
+		return "nothing changed"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// AddFields appends the fields the range supports and does not have yet
+func AddFields(cfg, support *Config, r Range, fields []Field) *FieldReport {
+	supportFields := *support.Fields(r.Section)
+	current := cfg.Fields(r.Section)
+	report := &FieldReport{}
+	for _, field := range fields {
+		switch {
+		case FieldIndex(supportFields, field.Field) < 0:
+			report.Unsupported = append(report.Unsupported, skipped(field.Field, field.Label, supportFields))
+		case FieldIndex(*current, field.Field) >= 0:
+			report.Duplicates = append(report.Duplicates, field.Field)
+		default:
+			*current = append(*current, field)
+			report.Added = append(report.Added, field.Field)
+		}
+	}
+	return report
+}
+
+// EditFields replaces the fields the range already has
+func EditFields(cfg *Config, r Range, fields []Field) *FieldReport {
+	current := cfg.Fields(r.Section)
+	report := &FieldReport{}
+	for _, field := range fields {
+		if index := FieldIndex(*current, field.Field); index >= 0 {
+			(*current)[index] = field
+			report.Updated = append(report.Updated, field.Field)
+		} else {
+			report.Missing = append(report.Missing, skipped(field.Field, field.Label, *current))
+		}
+	}
+	return report
+}
+
+// DeleteFields removes the named fields from the range
+func DeleteFields(cfg *Config, r Range, names []string) *FieldReport {
+	current := cfg.Fields(r.Section)
+	report := &FieldReport{}
+	for _, name := range names {
+		if index := FieldIndex(*current, name); index >= 0 {
+			*current = append((*current)[:index], (*current)[index+1:]...)
+			report.Deleted = append(report.Deleted, name)
+		} else {
+			report.Missing = append(report.Missing, skipped(name, "", *current))
+		}
+	}
+	return report
+}
+
+// skipped records a field that could not be applied with its closest candidates
+func skipped(name, label string, candidates []Field) SkippedField {
+	return SkippedField{Field: name, Label: label, Suggestions: Suggest(name, label, candidates)}
+}
+
+// maxSuggestions is the number of near misses suggested per field
+const maxSuggestions = 3
+
+// Suggest returns the candidates whose field code is close to name or whose label is close to label, closest first
+func Suggest(name, label string, candidates []Field) []Suggestion {
+	type scored struct {
+		field Field
+		score float64
+	}
+	var matches []scored
+	for _, candidate := range candidates {
+		score := 1.0
+		// 代码和中文名称分别比较，取更接近的一个
+		if name != "" {
+			score = min(score, closeness(strings.ToLower(name), strings.ToLower(candidate.Field)))
+		}
+		if label != "" && candidate.Label != "" {
+			score = min(score, closeness(label, candidate.Label))
+		}
+		// 名称也可能是用户说的中文名称
+		if name != "" && candidate.Label != "" {
+			score = min(score, closeness(name, candidate.Label))
+		}
+		if score <= 0.5 {
+			matches = append(matches, scored{candidate, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score < matches[j].score
+	})
+
+	suggestions := make([]Suggestion, 0, maxSuggestions)
+	for _, match := range matches {
+		if len(suggestions) == maxSuggestions {
+			break
+		}
+		suggestions = append(suggestions, Suggestion{Field: match.field.Field, Label: match.field.Label})
+	}
+	if len(suggestions) == 0 {
+		return nil
+	}
+	return suggestions
+}
+
+// closeness is 0 for equal strings, small when one contains the other or they differ by few runes, and 1 for
+// unrelated strings
+func closeness(a, b string) float64 {
+	if a == b {
+		return 0
+	}
+	if a == "" || b == "" {
+		return 1
+	}
+	if strings.Contains(a, b) || strings.Contains(b, a) {
+		return 0.25
+	}
+	longest := max(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+	return float64(levenshtein(a, b)) / float64(longest)
+}
+
+// levenshtein returns the edit distance between two strings in runes
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// describeSkipped lists skipped fields with their suggestions
+func describeSkipped(fields []SkippedField) string {
+	descriptions := make([]string, 0, len(fields))
+	for _, field := range fields {
+		descriptions = append(descriptions, field.Field+suggestionHint(field.Suggestions))
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// suggestionHint asks whether one of the suggestions was meant, empty when there are none
+func suggestionHint(suggestions []Suggestion) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(suggestions))
+	for _, s := range suggestions {
+		if s.Label != "" {
+			names = append(names, fmt.Sprintf("%s (%s)", s.Field, s.Label))
+		} else {
+			names = append(names, s.Field)
+		}
+	}
+	return " (did you mean " + strings.Join(names, ", ") + "?)"
+}
@@ -0,0 +1,97 @@
+package moduleconfig
+
+import (
+	"coder/internal/envelope"
+)
+
+// MoveTarget is where MoveField places a field; exactly one of Before, After and Index must be set
+type MoveTarget struct {
+	Before string `json:"before,omitempty"` // Place the field right before this field
+	After  string `json:"after,omitempty"`  // Place the field right after this field
+	Index  *int   `json:"index,omitempty"`  // Place the field at this 0-based position
+}
+
+// MoveField returns fields with the named field moved to target
+func MoveField(fields []Field, name string, target MoveTarget) ([]Field, error) {
+	from := FieldIndex(fields, name)
+	if from < 0 {
+		return nil, envelope.Errorf(envelope.CodeNotFound, "field '%s' does not exist, existing fields: %v", name, FieldNames(fields))
+	}
+	set := 0
+	for _, ok := range []bool{target.Before != "", target.After != "", target.Index != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, envelope.Errorf(envelope.CodeInvalidArguments, "exactly one of before, after and index must be given")
+	}
+
+	moving := fields[from]
+	rest := make([]Field, 0, len(fields))
+	rest = append(rest, fields[:from]...)
+	rest = append(rest, fields[from+1:]...)
+
+	var to int
+	switch {
+	case target.Index != nil:
+		to = *target.Index
+		if to < 0 || to >= len(fields) {
+			return nil, envelope.Errorf(envelope.CodeInvalidArguments, "index %d is out of range, must be between 0 and %d", to, len(fields)-1)
+		}
+	default:
+		anchor := target.Before + target.After
+		if anchor == name {
+			return nil, envelope.Errorf(envelope.CodeInvalidArguments, "field '%s' cannot be moved relative to itself", name)
+		}
+		to = FieldIndex(rest, anchor)
+		if to < 0 {
+			return nil, envelope.Errorf(envelope.CodeNotFound, "field '%s' does not exist, existing fields: %v", anchor, FieldNames(fields))
+		}
+		if target.After != "" {
+			to++
+		}
+	}
+
+	moved := make([]Field, 0, len(fields))
+	moved = append(moved, rest[:to]...)
+	moved = append(moved, moving)
+	moved = append(moved, rest[to:]...)
+	return moved, nil
+}
+
+// ReorderFields returns fields in the given order; fields not named in order keep their relative order after the
+// named ones
+func ReorderFields(fields []Field, order []string) ([]Field, error) {
+	if len(order) == 0 {
+		return nil, envelope.Errorf(envelope.CodeInvalidArguments, "order cannot be empty")
+	}
+	listed := make(map[string]bool)
+	reordered := make([]Field, 0, len(fields))
+	for _, name := range order {
+		if listed[name] {
+			return nil, envelope.Errorf(envelope.CodeInvalidArguments, "field '%s' is listed more than once in order", name)
+		}
+		index := FieldIndex(fields, name)
+		if index < 0 {
+			return nil, envelope.Errorf(envelope.CodeNotFound, "field '%s' does not exist, existing fields: %v", name, FieldNames(fields))
+		}
+		listed[name] = true
+		reordered = append(reordered, fields[index])
+	}
+	for _, field := range fields {
+		if !listed[field.Field] {
+			reordered = append(reordered, field)
+		}
+	}
+	return reordered, nil
+}
+
+// FieldNames returns the names of fields in order
+func FieldNames(fields []Field) []string {
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, field.Field)
+	}
+	return names
+}
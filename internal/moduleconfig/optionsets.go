@@ -0,0 +1,121 @@
+package moduleconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"coder/internal/envelope"
+)
+
+// Option is an entry of a select, radio or checkbox field
+type Option struct {
+	Label string      `json:"label"`
+	Value interface{} `json:"value"`
+}
+
+// OptionSet is a named list of options, a dictionary shared by the fields bound to it
+type OptionSet struct {
+	Name    string   `json:"name"`
+	Label   string   `json:"label,omitempty"`
+	Options []Option `json:"options"`
+}
+
+// OptionComponents are the form component types that take options
+var OptionComponents = []string{"select", "radio", "checkbox"}
+
+// IsOptionComponent reports whether fields of the component type take options
+func IsOptionComponent(componentType string) bool {
+	return contains(OptionComponents, componentType)
+}
+
+// OptionRanges are the codes of the ranges whose fields show the options of an option set
+var OptionRanges = []string{"create", "update", "search", "view"}
+
+// CheckOptionSet checks that an option set has a name and options with unique labels and values
+func CheckOptionSet(set OptionSet) error {
+	if strings.TrimSpace(set.Name) == "" {
+		return envelope.Errorf(envelope.CodeInvalidArguments, "option set name cannot be empty")
+	}
+	if err := CheckOptions(set.Options); err != nil {
+		return envelope.Errorf(envelope.CodeInvalidArguments, "option set '%s': %w", set.Name, err)
+	}
+	return nil
+}
+
+// CheckOptions checks that options are not empty and have unique labels and values
+func CheckOptions(options []Option) error {
+	if len(options) == 0 {
+		return fmt.Errorf("options cannot be empty")
+	}
+	labels := make(map[string]bool, len(options))
+	values := make(map[string]bool, len(options))
+	for i, option := range options {
+		if option.Label == "" || option.Value == nil {
+			return fmt.Errorf("options[%d] needs a label and a value", i)
+		}
+		// 值可能是字符串或数字，统一按文本比较
+		value := fmt.Sprint(option.Value)
+		if labels[option.Label] {
+			return fmt.Errorf("duplicate option label '%s'", option.Label)
+		}
+		if values[value] {
+			return fmt.Errorf("duplicate option value '%s'", value)
+		}
+		labels[option.Label] = true
+		values[value] = true
+	}
+	return nil
+}
+
+// ViewOptions is the options of a detail field, which shows the label of the value
+func ViewOptions(options []Option) map[string]interface{} {
+	return map[string]interface{}{
+		"map": options,
+		"chy": options,
+	}
+}
+
+// BindOptionSet sets the options of a field in a range to the option set and records the binding; form and search
+// fields must be of an option component type
+func BindOptionSet(field *Field, r Range, set OptionSet) error {
+	if r.Section == "viewConfig" {
+		field.Options = ViewOptions(set.Options)
+		field.OptionSet = set.Name
+		return nil
+	}
+	componentType := field.Type
+	if componentType == "" {
+		componentType = "input"
+	}
+	if !IsOptionComponent(componentType) {
+		return envelope.Errorf(envelope.CodeUnsupported, "field '%s' in %s has component type '%s', options only apply to %s", field.Field, r.Section, componentType, strings.Join(OptionComponents, ", "))
+	}
+	field.Options = set.Options
+	field.OptionSet = set.Name
+	return nil
+}
+
+// RebindOptionSet refreshes the options of every field bound to the option set and returns them as section.field
+func RebindOptionSet(cfg *Config, set OptionSet) []string {
+	var updated []string
+	for _, code := range OptionRanges {
+		r, _ := LookupRange(code)
+		fields := *cfg.Fields(r.Section)
+		for i := range fields {
+			if fields[i].OptionSet != set.Name {
+				continue
+			}
+			// 绑定时已校验组件类型，这里只刷新选项
+			if err := BindOptionSet(&fields[i], r, set); err == nil {
+				updated = append(updated, r.Section+"."+fields[i].Field)
+			}
+		}
+	}
+	return updated
+}
+
+// OptionTargets finds the field with the given code in the given ranges, or in every range of OptionRanges that
+// has it when no range is given
+func OptionTargets(cfg *Config, rangeCodes []string, name string) ([]FieldTarget, error) {
+	return FieldTargets(cfg, OptionRanges, rangeCodes, name, "options")
+}
@@ -0,0 +1,114 @@
+package moduleconfig
+
+import (
+	"strings"
+
+	"coder/internal/envelope"
+)
+
+// MaxColumns is the largest number of columns of the form grid
+const MaxColumns = 4
+
+// DefaultColumns is the number of columns of generated forms
+const DefaultColumns = 1
+
+// layoutValues are the layouts the dynamicForm renderer offers for each page, see SetLayoutValues
+var layoutValues map[string][]string
+
+// SetLayoutValues sets the layouts the dynamicForm renderer offers for each page ("table" and "form"),
+// usually from the [module] layouts configuration; it must be called before the tools are created
+func SetLayoutValues(values map[string][]string) {
+	layoutValues = make(map[string][]string, len(values))
+	for page, pageValues := range values {
+		layoutValues[page] = append([]string(nil), pageValues...)
+	}
+}
+
+// DefaultLayout is the layout of generated pages
+func DefaultLayout() *Layout {
+	return &Layout{Table: "Content", Form: "TitleContent"}
+}
+
+// DefaultPageName is the titles of generated pages of an entity with the given Chinese name
+func DefaultPageName(name string) *PageName {
+	return &PageName{Table: name, New: "新增" + name, Edit: "更改" + name}
+}
+
+// AllowedLayouts returns the layouts allowed for each page: the default layout, the configured layout values
+// and the layouts used in support
+func AllowedLayouts(support *Config) map[string][]string {
+	allowed := make(map[string][]string, 2)
+	add := func(page, value string) {
+		if value != "" && !contains(allowed[page], value) {
+			allowed[page] = append(allowed[page], value)
+		}
+	}
+
+	// 生成的页面使用默认布局，始终允许
+	defaults := DefaultLayout()
+	add("table", defaults.Table)
+	add("form", defaults.Form)
+	for page, values := range layoutValues {
+		for _, value := range values {
+			add(page, value)
+		}
+	}
+	if support != nil && support.Layout != nil {
+		add("table", support.Layout.Table)
+		add("form", support.Layout.Form)
+	}
+	return allowed
+}
+
+// SetPageNames sets the non-empty titles of names and returns the keys it changed
+func SetPageNames(cfg *Config, names PageName) []string {
+	if cfg.PageName == nil {
+		cfg.PageName = &PageName{}
+	}
+	var changed []string
+	for _, title := range []struct {
+		key   string
+		value string
+		dst   *string
+	}{
+		{"table", names.Table, &cfg.PageName.Table},
+		{"new", names.New, &cfg.PageName.New},
+		{"edit", names.Edit, &cfg.PageName.Edit},
+		{"name", names.Name, &cfg.PageName.Name},
+	} {
+		if title.value != "" && title.value != *title.dst {
+			*title.dst = title.value
+			changed = append(changed, title.key)
+		}
+	}
+	return changed
+}
+
+// SetLayout sets the non-empty page layouts of layout after checking them against the allowed layouts
+func SetLayout(cfg, support *Config, layout Layout) error {
+	allowed := AllowedLayouts(support)
+	for _, page := range []struct{ name, value string }{{"table", layout.Table}, {"form", layout.Form}} {
+		if page.value != "" && !contains(allowed[page.name], page.value) {
+			return envelope.Errorf(envelope.CodeUnsupported, "layout '%s' is not supported for the %s page, must be one of: %s", page.value, page.name, strings.Join(allowed[page.name], ", "))
+		}
+	}
+	if cfg.Layout == nil {
+		cfg.Layout = &Layout{}
+	}
+	if layout.Table != "" {
+		cfg.Layout.Table = layout.Table
+	}
+	if layout.Form != "" {
+		cfg.Layout.Form = layout.Form
+	}
+	return nil
+}
+
+// SetColumns sets the number of columns of the form grid
+func SetColumns(cfg *Config, columns int) error {
+	if columns < 1 || columns > MaxColumns {
+		return envelope.Errorf(envelope.CodeInvalidArguments, "columns must be between 1 and %d, got %d", MaxColumns, columns)
+	}
+	cfg.Columns = &columns
+	return nil
+}
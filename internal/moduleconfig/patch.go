@@ -0,0 +1,300 @@
+package moduleconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"coder/internal/envelope"
+)
+
+// PatchOperation is an RFC 6902 JSON Patch operation
+type PatchOperation struct {
+	Op    string          `json:"op"`             // add, remove, replace, move, copy or test
+	Path  string          `json:"path"`           // RFC 6901 JSON Pointer, such as /createFields/0/label
+	From  string          `json:"from,omitempty"` // Source pointer of move and copy
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Patch applies an RFC 7386 merge patch and then RFC 6902 JSON Patch operations to a copy of cfg; either may be
+// empty. The result is decoded again so that type errors are reported like those of a malformed configuration.
+func Patch(cfg *Config, mergePatch json.RawMessage, ops []PatchOperation) (*Config, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal module config: %w", err)
+	}
+	doc, err := decodeValue(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode module config: %w", err)
+	}
+
+	if len(bytes.TrimSpace(mergePatch)) > 0 {
+		patch, err := decodeValue(mergePatch)
+		if err != nil {
+			return nil, envelope.Errorf(envelope.CodeInvalidArguments, "merge_patch is not valid JSON: %w", err)
+		}
+		doc = MergePatch(doc, patch)
+	}
+	for index, op := range ops {
+		doc, err = applyOperation(doc, op)
+		if err != nil {
+			return nil, envelope.Errorf(envelope.CodeInvalidArguments, "patch[%d] (%s %s): %w", index, op.Op, op.Path, err)
+		}
+	}
+
+	if _, ok := doc.(map[string]interface{}); !ok {
+		return nil, envelope.Errorf(envelope.CodeInvalidConfig, "the patched module config must be an object")
+	}
+	patched, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal patched module config: %w", err)
+	}
+	return Parse(string(patched))
+}
+
+// MergePatch applies an RFC 7386 merge patch to a decoded JSON document
+func MergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = MergePatch(t[key], value)
+		}
+	}
+	return t
+}
+
+// applyOperation applies one JSON Patch operation to a decoded JSON document
+func applyOperation(doc interface{}, op PatchOperation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, fmt.Errorf("value is required")
+		}
+		value, err := decodeValue(op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("value is not valid JSON: %w", err)
+		}
+		switch op.Op {
+		case "add":
+			return addValue(doc, path, value)
+		case "replace":
+			return replaceValue(doc, path, value)
+		}
+		current, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("test failed, the value is %s", encodeValue(current))
+		}
+		return doc, nil
+
+	case "remove":
+		return removeValue(doc, path)
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		if op.Op == "move" && len(path) > len(from) && strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("cannot move a value into itself")
+		}
+		value, err := getValue(doc, from)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		if op.Op == "move" {
+			if doc, err = removeValue(doc, from); err != nil {
+				return nil, fmt.Errorf("from: %w", err)
+			}
+		} else if value, err = decodeValue([]byte(encodeValue(value))); err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	}
+	return nil, fmt.Errorf("unknown op '%s', must be one of: add, remove, replace, move, copy, test", op.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path '%s' must be empty or start with '/'", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// getValue returns the value at path
+func getValue(doc interface{}, path []string) (interface{}, error) {
+	for i, token := range path {
+		switch container := doc.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("'%s' does not exist", pointerOf(path[:i+1]))
+			}
+			doc = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", pointerOf(path[:i+1]), err)
+			}
+			doc = container[index]
+		default:
+			return nil, fmt.Errorf("'%s' is not an object or a list", pointerOf(path[:i]))
+		}
+	}
+	return doc, nil
+}
+
+// addValue adds value at path; list positions insert and '-' appends
+func addValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	return updateParent(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			index := len(container)
+			if token != "-" {
+				var err error
+				if index, err = arrayIndex(token, len(container), true); err != nil {
+					return nil, err
+				}
+			}
+			result := make([]interface{}, 0, len(container)+1)
+			result = append(result, container[:index]...)
+			result = append(result, value)
+			return append(result, container[index:]...), nil
+		}
+		return nil, fmt.Errorf("the parent of '%s' is not an object or a list", token)
+	}, value)
+}
+
+// replaceValue replaces the existing value at path
+func replaceValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if _, err := getValue(doc, path); err != nil {
+		return nil, err
+	}
+	return updateParent(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			container[token] = value
+		case []interface{}:
+			index, _ := arrayIndex(token, len(container), false)
+			container[index] = value
+		}
+		return parent, nil
+	}, value)
+}
+
+// removeValue removes the existing value at path
+func removeValue(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot remove the whole module config")
+	}
+	if _, err := getValue(doc, path); err != nil {
+		return nil, err
+	}
+	return updateParent(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			delete(container, token)
+			return container, nil
+		case []interface{}:
+			index, _ := arrayIndex(token, len(container), false)
+			return append(container[:index:index], container[index+1:]...), nil
+		}
+		return parent, nil
+	}, nil)
+}
+
+// updateParent applies update to the container holding the last token of path and stores the updated container
+// back into its own parent; an empty path replaces the whole document with root
+func updateParent(doc interface{}, path []string, update func(parent interface{}, token string) (interface{}, error), root interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return root, nil
+	}
+	if len(path) == 1 {
+		return update(doc, path[0])
+	}
+	child, err := getValue(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = updateParent(child, path[1:], update, root)
+	if err != nil {
+		return nil, err
+	}
+	switch container := doc.(type) {
+	case map[string]interface{}:
+		container[path[0]] = child
+	case []interface{}:
+		index, _ := arrayIndex(path[0], len(container), false)
+		container[index] = child
+	}
+	return doc, nil
+}
+
+// arrayIndex parses the index of an item of a list of length items; insert allows the position after the last item
+func arrayIndex(token string, length int, insert bool) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("'%s' is not a valid list index", token)
+	}
+	if index > length || (index == length && !insert) {
+		return 0, fmt.Errorf("index %d is out of range, the list has %d items", index, length)
+	}
+	return index, nil
+}
+
+// pointerOf joins tokens back into a JSON Pointer
+func pointerOf(path []string) string {
+	var b strings.Builder
+	for _, token := range path {
+		b.WriteString("/")
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// decodeValue decodes JSON keeping numbers exact
+func decodeValue(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// encodeValue encodes a decoded value for messages
+func encodeValue(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
@@ -0,0 +1,205 @@
+package moduleconfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"coder/internal/envelope"
+)
+
+func TestPatch(t *testing.T) {
+	const base = `{"createFields":[{"field":"a","label":"A"},{"field":"b","label":"B"}],"listAPI":"/list","a/b":1,"m~n":1}`
+
+	tests := []struct {
+		name       string
+		mergePatch string
+		ops        string
+		want       string
+		wantCode   string
+		wantErr    string
+	}{
+		{
+			name: "~1 escapes a slash in a key",
+			ops:  `[{"op":"replace","path":"/a~1b","value":2}]`,
+			want: `{"createFields":[{"field":"a","label":"A"},{"field":"b","label":"B"}],"listAPI":"/list","a/b":2,"m~n":1}`,
+		},
+		{
+			name: "~0 escapes a tilde in a key",
+			ops:  `[{"op":"remove","path":"/m~0n"}]`,
+			want: `{"createFields":[{"field":"a","label":"A"},{"field":"b","label":"B"}],"listAPI":"/list","a/b":1}`,
+		},
+		{
+			name: "~01 unescapes to ~1 and not to a slash",
+			ops:  `[{"op":"add","path":"/~01","value":3}]`,
+			want: `{"createFields":[{"field":"a","label":"A"},{"field":"b","label":"B"}],"listAPI":"/list","a/b":1,"m~n":1,"~1":3}`,
+		},
+		{
+			name: "- appends to a list",
+			ops:  `[{"op":"add","path":"/createFields/-","value":{"field":"c","label":"C"}}]`,
+			want: `{"createFields":[{"field":"a","label":"A"},{"field":"b","label":"B"},{"field":"c","label":"C"}],"listAPI":"/list","a/b":1,"m~n":1}`,
+		},
+		{
+			name: "index inserts before the item",
+			ops:  `[{"op":"add","path":"/createFields/0","value":{"field":"c","label":"C"}}]`,
+			want: `{"createFields":[{"field":"c","label":"C"},{"field":"a","label":"A"},{"field":"b","label":"B"}],"listAPI":"/list","a/b":1,"m~n":1}`,
+		},
+		{
+			name: "move reorders a list",
+			ops:  `[{"op":"move","from":"/createFields/1","path":"/createFields/0"}]`,
+			want: `{"createFields":[{"field":"b","label":"B"},{"field":"a","label":"A"}],"listAPI":"/list","a/b":1,"m~n":1}`,
+		},
+		{
+			name: "copy is not shared with its source",
+			ops:  `[{"op":"copy","from":"/createFields/0","path":"/createFields/-"},{"op":"replace","path":"/createFields/2/label","value":"A2"}]`,
+			want: `{"createFields":[{"field":"a","label":"A"},{"field":"b","label":"B"},{"field":"a","label":"A2"}],"listAPI":"/list","a/b":1,"m~n":1}`,
+		},
+		{
+			name: "passing test lets the following ops apply",
+			ops:  `[{"op":"test","path":"/createFields/0/label","value":"A"},{"op":"replace","path":"/createFields/0/label","value":"A2"}]`,
+			want: `{"createFields":[{"field":"a","label":"A2"},{"field":"b","label":"B"}],"listAPI":"/list","a/b":1,"m~n":1}`,
+		},
+		{
+			name:       "merge patch is applied before the ops",
+			mergePatch: `{"listAPI":null,"createAPI":"/create","m~n":null}`,
+			ops:        `[{"op":"test","path":"/createAPI","value":"/create"}]`,
+			want:       `{"createFields":[{"field":"a","label":"A"},{"field":"b","label":"B"}],"createAPI":"/create","a/b":1}`,
+		},
+		{
+			name:     "failing test",
+			ops:      `[{"op":"test","path":"/createFields/0/label","value":"B"}]`,
+			wantCode: envelope.CodeInvalidArguments,
+			wantErr:  `patch[0] (test /createFields/0/label): test failed, the value is "A"`,
+		},
+		{
+			name:     "failing op after applied ops",
+			ops:      `[{"op":"replace","path":"/listAPI","value":"/other"},{"op":"remove","path":"/createFields/5"}]`,
+			wantCode: envelope.CodeInvalidArguments,
+			wantErr:  "patch[1] (remove /createFields/5): /createFields/5: index 5 is out of range, the list has 2 items",
+		},
+		{
+			name:     "- only appends",
+			ops:      `[{"op":"replace","path":"/createFields/-","value":{"field":"c"}}]`,
+			wantCode: envelope.CodeInvalidArguments,
+			wantErr:  "'-' is not a valid list index",
+		},
+		{
+			name:     "index with a leading zero",
+			ops:      `[{"op":"add","path":"/createFields/01","value":{"field":"c"}}]`,
+			wantCode: envelope.CodeInvalidArguments,
+			wantErr:  "'01' is not a valid list index",
+		},
+		{
+			name:     "move into itself",
+			ops:      `[{"op":"move","from":"/createFields","path":"/createFields/0"}]`,
+			wantCode: envelope.CodeInvalidArguments,
+			wantErr:  "cannot move a value into itself",
+		},
+		{
+			name:     "unknown op",
+			ops:      `[{"op":"append","path":"/listAPI","value":"x"}]`,
+			wantCode: envelope.CodeInvalidArguments,
+			wantErr:  "unknown op 'append'",
+		},
+		{
+			name:     "path without a leading slash",
+			ops:      `[{"op":"remove","path":"listAPI"}]`,
+			wantCode: envelope.CodeInvalidArguments,
+			wantErr:  "path 'listAPI' must be empty or start with '/'",
+		},
+		{
+			name:     "patched config of the wrong type",
+			ops:      `[{"op":"add","path":"/columns","value":"two"}]`,
+			wantCode: envelope.CodeInvalidConfig,
+		},
+		{
+			name:     "patched config that is not an object",
+			ops:      `[{"op":"replace","path":"","value":[]}]`,
+			wantCode: envelope.CodeInvalidConfig,
+			wantErr:  "the patched module config must be an object",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse(base)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			var ops []PatchOperation
+			if err := json.Unmarshal([]byte(tt.ops), &ops); err != nil {
+				t.Fatalf("invalid ops: %v", err)
+			}
+
+			got, err := Patch(cfg, json.RawMessage(tt.mergePatch), ops)
+
+			// 无论成功与否，原配置都不能被修改
+			after, _ := cfg.JSON()
+			original, _ := mustParse(t, base).JSON()
+			if after != original {
+				t.Errorf("Patch() modified its input: %s", after)
+			}
+
+			if tt.wantCode != "" {
+				if err == nil {
+					t.Fatalf("Patch() = %v, want a %s error", got, tt.wantCode)
+				}
+				if code := envelope.CodeOf(err); code != tt.wantCode {
+					t.Errorf("Patch() error code = %s, want %s (%v)", code, tt.wantCode, err)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Patch() error = %q, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Patch() error = %v", err)
+			}
+			if want := mustParse(t, tt.want); !reflect.DeepEqual(got, want) {
+				gotJSON, _ := got.JSON()
+				wantJSON, _ := want.JSON()
+				t.Errorf("Patch() = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestParsePointer(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    []string
+	}{
+		{pointer: "", want: nil},
+		{pointer: "/", want: []string{""}},
+		{pointer: "/createFields/0/label", want: []string{"createFields", "0", "label"}},
+		{pointer: "/a~1b/m~0n", want: []string{"a/b", "m~n"}},
+		{pointer: "/~01", want: []string{"~1"}},
+		{pointer: "/~10", want: []string{"/0"}},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePointer(tt.pointer)
+		if err != nil {
+			t.Errorf("parsePointer(%q) error = %v", tt.pointer, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePointer(%q) = %q, want %q", tt.pointer, got, tt.want)
+		}
+		// 还原后应得到原指针
+		if back := pointerOf(got); back != tt.pointer {
+			t.Errorf("pointerOf(%q) = %q, want %q", got, back, tt.pointer)
+		}
+	}
+}
+
+// mustParse parses a module configuration of a test case
+func mustParse(t *testing.T, config string) *Config {
+	t.Helper()
+	cfg, err := Parse(config)
+	if err != nil {
+		t.Fatalf("Parse(%s) error = %v", config, err)
+	}
+	return cfg
+}
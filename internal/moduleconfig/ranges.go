@@ -0,0 +1,111 @@
+package moduleconfig
+
+import (
+	"strings"
+
+	"coder/internal/envelope"
+)
+
+// Range is a part of a module page whose fields are configured under one configuration key
+type Range struct {
+	Code    string   `json:"code"`    // Code used by the tools, such as create
+	Name    string   `json:"name"`    // Chinese name
+	Section string   `json:"section"` // Configuration key holding the fields, such as createFields
+	Aliases []string `json:"aliases"` // Other names users call the range by
+}
+
+// Ranges is the registry of field ranges; a new section only needs an entry here and a case in Config.Fields
+var Ranges = []Range{
+	{Code: "view", Name: "详情", Section: "viewConfig", Aliases: []string{"查看", "详情页", "detail"}},
+	{Code: "create", Name: "创建", Section: "createFields", Aliases: []string{"新建", "添加", "新增", "add", "new"}},
+	{Code: "update", Name: "更新", Section: "updateFields", Aliases: []string{"修改", "编辑", "edit"}},
+	{Code: "list", Name: "列表", Section: "tableFields", Aliases: []string{"分页", "表格", "table"}},
+	{Code: "search", Name: "搜索", Section: "searchFields", Aliases: []string{"查询", "筛选", "filter"}},
+}
+
+// LookupRange finds a range by its code, Chinese name, alias or configuration key
+func LookupRange(name string) (Range, bool) {
+	name = strings.TrimSpace(name)
+	for _, r := range Ranges {
+		if strings.EqualFold(name, r.Code) || name == r.Name || name == r.Section {
+			return r, true
+		}
+		for _, alias := range r.Aliases {
+			if strings.EqualFold(name, alias) {
+				return r, true
+			}
+		}
+	}
+	return Range{}, false
+}
+
+// ResolveRange finds the range named by a tool argument and rejects unknown ranges
+func ResolveRange(name string) (Range, error) {
+	if r, ok := LookupRange(name); ok {
+		return r, nil
+	}
+	return Range{}, envelope.Errorf(envelope.CodeInvalidArguments, "unknown range '%s', must be one of: %s", name, RangeCodes())
+}
+
+// RangeCodes describes the known ranges for tool descriptions and error messages
+func RangeCodes() string {
+	codes := make([]string, 0, len(Ranges))
+	for _, r := range Ranges {
+		codes = append(codes, r.Code+" ("+strings.Join(append([]string{r.Name}, r.Aliases...), "/")+")")
+	}
+	return strings.Join(codes, ", ")
+}
+
+// FieldTarget is a field edited in one range
+type FieldTarget struct {
+	Range Range
+	Field *Field
+}
+
+// FieldTargets finds the field with the given code in the given ranges, which must be among allowed, or in every
+// allowed range that has it when no range is given; what names the setting for error messages
+func FieldTargets(cfg *Config, allowed, rangeCodes []string, name, what string) ([]FieldTarget, error) {
+	explicit := len(rangeCodes) > 0
+	if !explicit {
+		rangeCodes = allowed
+	}
+
+	var targets []FieldTarget
+	var candidates []Field
+	sections := make([]string, 0, len(rangeCodes))
+	for _, code := range rangeCodes {
+		r, err := ResolveRange(code)
+		if err != nil {
+			return nil, err
+		}
+		if !contains(allowed, r.Code) {
+			return nil, envelope.Errorf(envelope.CodeInvalidArguments, "range '%s' has no %s, must be one of: %s", code, what, strings.Join(allowed, ", "))
+		}
+		sections = append(sections, r.Section)
+		fields := cfg.Fields(r.Section)
+		index := FieldIndex(*fields, name)
+		if index < 0 {
+			if explicit {
+				return nil, fieldNotFound(name, r.Section, *fields)
+			}
+			// 各范围通常有相同的字段，建议时去重
+			for _, field := range *fields {
+				if FieldIndex(candidates, field.Field) < 0 {
+					candidates = append(candidates, field)
+				}
+			}
+			continue
+		}
+		targets = append(targets, FieldTarget{Range: r, Field: &(*fields)[index]})
+	}
+	if len(targets) == 0 {
+		return nil, fieldNotFound(name, strings.Join(sections, "/"), candidates)
+	}
+	return targets, nil
+}
+
+// fieldNotFound reports a missing field with the closest fields as suggestions
+func fieldNotFound(name, section string, candidates []Field) error {
+	missing := skipped(name, "", candidates)
+	return envelope.ErrorWithData(envelope.CodeNotFound, missing, "field '%s' does not exist in %s%s", name, section, suggestionHint(missing.Suggestions))
+}
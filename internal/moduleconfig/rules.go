@@ -0,0 +1,224 @@
+package moduleconfig
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RuleSpec describes a rule type of the field rule vocabulary
+type RuleSpec struct {
+	Type       string   `json:"type"`
+	Desc       string   `json:"desc"`
+	Params     []string `json:"params,omitempty"`     // Parameters besides type and message
+	Components []string `json:"components,omitempty"` // Component types the rule applies to, empty for all
+}
+
+// 文本类和数字类组件
+var (
+	textComponents   = []string{"input", "textarea", "password", "editor"}
+	numberComponents = []string{"number"}
+)
+
+// RuleSpecs is the vocabulary of field rules; every rule may also carry a custom message
+var RuleSpecs = []RuleSpec{
+	{Type: "required", Desc: "the field must be filled in"},
+	{Type: "length", Desc: "text length, with len for an exact length or min and/or max", Params: []string{"len", "min", "max"}, Components: textComponents},
+	{Type: "pattern", Desc: "text must match the regular expression pattern", Params: []string{"pattern"}, Components: textComponents},
+	{Type: "range", Desc: "number between min and/or max", Params: []string{"min", "max"}, Components: numberComponents},
+	{Type: "email", Desc: "text must be an email address", Components: textComponents},
+	{Type: "phone", Desc: "text must be a mainland China mobile number (11 digits)", Components: textComponents},
+	{Type: "url", Desc: "text must be a URL", Components: textComponents},
+}
+
+// RuleVocabulary describes the rule types for tool descriptions
+func RuleVocabulary() string {
+	descriptions := make([]string, 0, len(RuleSpecs))
+	for _, spec := range RuleSpecs {
+		description := spec.Type + ": " + spec.Desc
+		if len(spec.Components) > 0 {
+			description += " (" + strings.Join(spec.Components, "/") + ")"
+		}
+		descriptions = append(descriptions, description)
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// LookupRuleSpec finds a rule type of the vocabulary
+func LookupRuleSpec(ruleType string) (RuleSpec, bool) {
+	for _, spec := range RuleSpecs {
+		if spec.Type == ruleType {
+			return spec, true
+		}
+	}
+	return RuleSpec{}, false
+}
+
+// CheckRule checks a rule against the vocabulary and the component type of its field; component types the
+// vocabulary does not know accept every rule
+func CheckRule(rule map[string]interface{}, componentType string) error {
+	ruleType, _ := rule["type"].(string)
+	if ruleType == "" {
+		return fmt.Errorf("rule type is required")
+	}
+	spec, ok := LookupRuleSpec(ruleType)
+	if !ok {
+		return fmt.Errorf("unknown rule type '%s', must be one of: %s", ruleType, strings.Join(ruleTypes(), ", "))
+	}
+	if componentType == "" {
+		componentType = "input"
+	}
+	if len(spec.Components) > 0 && isKnownComponent(componentType) && !contains(spec.Components, componentType) {
+		return fmt.Errorf("rule '%s' does not apply to component type '%s', only to %s", ruleType, componentType, strings.Join(spec.Components, ", "))
+	}
+
+	keys := make([]string, 0, len(rule))
+	for key := range rule {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key != "type" && key != "message" && !contains(spec.Params, key) {
+			return fmt.Errorf("rule '%s' has no parameter '%s'", ruleType, key)
+		}
+	}
+	if message, ok := rule["message"]; ok {
+		if _, ok := message.(string); !ok {
+			return fmt.Errorf("rule '%s' message must be a string", ruleType)
+		}
+	}
+
+	switch ruleType {
+	case "length", "range":
+		bounds := make(map[string]float64)
+		for _, key := range spec.Params {
+			value, ok := rule[key]
+			if !ok {
+				continue
+			}
+			number, ok := toNumber(value)
+			if !ok {
+				return fmt.Errorf("rule '%s' %s must be a number, got %v", ruleType, key, value)
+			}
+			if ruleType == "length" && (number < 0 || number != float64(int(number))) {
+				return fmt.Errorf("rule 'length' %s must be a non-negative integer, got %v", key, value)
+			}
+			bounds[key] = number
+		}
+		if len(bounds) == 0 {
+			return fmt.Errorf("rule '%s' needs at least one of %s", ruleType, strings.Join(spec.Params, ", "))
+		}
+		lower, hasMin := bounds["min"]
+		upper, hasMax := bounds["max"]
+		if hasMin && hasMax && lower > upper {
+			return fmt.Errorf("rule '%s' min %v is greater than max %v", ruleType, lower, upper)
+		}
+		if _, hasLen := bounds["len"]; hasLen && (hasMin || hasMax) {
+			return fmt.Errorf("rule 'length' takes either len or min/max")
+		}
+	case "pattern":
+		pattern, _ := rule["pattern"].(string)
+		if pattern == "" {
+			return fmt.Errorf("rule 'pattern' needs a pattern")
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("rule 'pattern' has an invalid regular expression: %v", err)
+		}
+	}
+	return nil
+}
+
+// SetRules adds rules to a field after checking them; a rule replaces the field's rule of the same type
+func SetRules(field *Field, rules []map[string]interface{}) error {
+	for _, rule := range rules {
+		if err := CheckRule(rule, field.Type); err != nil {
+			return err
+		}
+	}
+	for _, rule := range rules {
+		// 每个字段持有自己的规则副本，多个范围设置同一规则时互不影响
+		copied := make(map[string]interface{}, len(rule))
+		for key, value := range rule {
+			copied[key] = value
+		}
+		rule = copied
+		index := ruleIndex(field.Rules, rule["type"].(string))
+		if index >= 0 {
+			field.Rules[index] = rule
+		} else {
+			field.Rules = append(field.Rules, rule)
+		}
+	}
+	return nil
+}
+
+// RemoveRules removes the rules of the given types from a field, all rules when no type is given, and returns
+// the types removed
+func RemoveRules(field *Field, types []string) []string {
+	removed := make([]string, 0, len(field.Rules))
+	kept := make([]map[string]interface{}, 0, len(field.Rules))
+	for _, rule := range field.Rules {
+		ruleType, _ := rule["type"].(string)
+		if len(types) == 0 || contains(types, ruleType) {
+			removed = append(removed, ruleType)
+		} else {
+			kept = append(kept, rule)
+		}
+	}
+	field.Rules = kept
+	if len(kept) == 0 {
+		field.Rules = nil
+	}
+	return removed
+}
+
+// RuleRanges are the codes of the form ranges whose fields carry rules
+var RuleRanges = []string{"create", "update"}
+
+// RuleTargets finds the field with the given code in the given form ranges, or in every range of RuleRanges that
+// has it when no range is given
+func RuleTargets(cfg *Config, rangeCodes []string, name string) ([]FieldTarget, error) {
+	return FieldTargets(cfg, RuleRanges, rangeCodes, name, "field rules")
+}
+
+// ruleIndex returns the position of the rule of the given type, or -1
+func ruleIndex(rules []map[string]interface{}, ruleType string) int {
+	for index, rule := range rules {
+		if rule["type"] == ruleType {
+			return index
+		}
+	}
+	return -1
+}
+
+func ruleTypes() []string {
+	types := make([]string, 0, len(RuleSpecs))
+	for _, spec := range RuleSpecs {
+		types = append(types, spec.Type)
+	}
+	return types
+}
+
+func isKnownComponent(componentType string) bool {
+	return contains(ComponentTypes, componentType)
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
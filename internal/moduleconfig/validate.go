@@ -0,0 +1,178 @@
+package moduleconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"coder/internal/envelope"
+)
+
+// ComponentTypes are the component types the dynamicForm renderer knows; types used in support are accepted too
+var ComponentTypes = []string{
+	"input", "textarea", "password", "number", "select", "radio", "checkbox", "switch",
+	"date", "datetime", "daterange", "time", "upload", "image", "editor", "cascader",
+	"tree", "text", "plain", "tag", "link",
+}
+
+// Issue is a problem found in a module configuration
+type Issue struct {
+	Path    string `json:"path"`    // Location of the problem, such as createFields[name].label
+	Message string `json:"message"` // What is wrong
+}
+
+func (i Issue) String() string {
+	return i.Path + ": " + i.Message
+}
+
+// Validate checks a module configuration against the module support configuration and returns every problem found
+func Validate(cfg, support *Config) []Issue {
+	if support == nil {
+		support = &Config{}
+	}
+	v := &validator{types: make(map[string]bool)}
+	for _, t := range ComponentTypes {
+		v.types[t] = true
+	}
+	for _, r := range Ranges {
+		for _, field := range *support.Fields(r.Section) {
+			if field.Type != "" {
+				v.types[field.Type] = true
+			}
+		}
+	}
+
+	for _, r := range Ranges {
+		v.fields(r.Section, *cfg.Fields(r.Section), support)
+	}
+	for _, section := range ActionSections {
+		v.actions(section, *cfg.Actions(section))
+	}
+	for _, key := range APIKeys {
+		url := *cfg.API(key)
+		if url != "" && !strings.HasPrefix(url, "/") && !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			v.add(key, "URL '%s' must start with '/', 'http://' or 'https://'", url)
+		}
+	}
+	if cfg.Columns != nil && (*cfg.Columns < 1 || *cfg.Columns > MaxColumns) {
+		v.add("columns", "must be between 1 and %d, got %d", MaxColumns, *cfg.Columns)
+	}
+	if cfg.Layout != nil {
+		allowed := AllowedLayouts(support)
+		for _, page := range []struct{ name, value string }{{"table", cfg.Layout.Table}, {"form", cfg.Layout.Form}} {
+			if page.value != "" && !contains(allowed[page.name], page.value) {
+				v.add("layout."+page.name, "layout '%s' is not supported, must be one of: %s", page.value, strings.Join(allowed[page.name], ", "))
+			}
+		}
+	}
+	return v.issues
+}
+
+// ValidateChange validates after and rejects only the problems that before does not already have, so that
+// problems inherited from the server do not block unrelated edits
+func ValidateChange(before, after, support *Config) error {
+	return validateNew([]*Config{before}, after, support)
+}
+
+// ValidateMerge validates the merge of a draft with the server configuration and rejects only the problems that
+// neither of them already has
+func ValidateMerge(draft, server, merged, support *Config) error {
+	return validateNew([]*Config{draft, server}, merged, support)
+}
+
+// validateNew validates after and returns the problems that none of the known configurations has
+func validateNew(known []*Config, after, support *Config) error {
+	existing := make(map[string]bool)
+	for _, cfg := range known {
+		if cfg == nil {
+			continue
+		}
+		for _, issue := range Validate(cfg, support) {
+			existing[issue.String()] = true
+		}
+	}
+	var issues []Issue
+	for _, issue := range Validate(after, support) {
+		if !existing[issue.String()] {
+			issues = append(issues, issue)
+		}
+	}
+	return issuesError(issues)
+}
+
+// issuesError wraps issues into an invalid_config error, nil when there are none
+func issuesError(issues []Issue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	messages := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		messages = append(messages, issue.String())
+	}
+	return envelope.ErrorWithData(envelope.CodeInvalidConfig, map[string]interface{}{"issues": issues},
+		"invalid module config: %s", strings.Join(messages, "; "))
+}
+
+type validator struct {
+	types  map[string]bool
+	issues []Issue
+}
+
+func (v *validator) add(path, format string, args ...interface{}) {
+	v.issues = append(v.issues, Issue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// fields checks the fields of a section
+func (v *validator) fields(section string, fields []Field, support *Config) {
+	supported := make(map[string]bool)
+	supportFields := *support.Fields(section)
+	for _, field := range supportFields {
+		supported[field.Field] = true
+	}
+
+	seen := make(map[string]bool)
+	for index, field := range fields {
+		path := fmt.Sprintf("%s[%s]", section, field.Field)
+		if field.Field == "" {
+			path = fmt.Sprintf("%s[#%d]", section, index)
+			v.add(path+".field", "field is required")
+		} else if seen[field.Field] {
+			v.add(path, "duplicate field '%s'", field.Field)
+		}
+		seen[field.Field] = true
+
+		if field.Label == "" {
+			v.add(path+".label", "label is required")
+		}
+		if field.Type != "" && !v.types[field.Type] {
+			v.add(path+".type", "unknown component type '%s'", field.Type)
+		}
+		for _, rule := range field.Rules {
+			if err := CheckRule(rule, field.Type); err != nil {
+				v.add(path+".rules", "%v", err)
+			}
+		}
+		// 支持配置中有该范围时，字段必须是支持的字段
+		if field.Field != "" && len(supportFields) > 0 && !supported[field.Field] {
+			v.add(path, "field '%s' is not supported in %s", field.Field, section)
+		}
+	}
+}
+
+// actions checks the actions or operations of a section
+func (v *validator) actions(section string, actions []Action) {
+	seen := make(map[string]bool)
+	for index, action := range actions {
+		path := fmt.Sprintf("%s[%s]", section, action.Title)
+		if action.Title == "" {
+			path = fmt.Sprintf("%s[#%d]", section, index)
+			v.add(path+".title", "title is required")
+		} else if seen[action.Title] {
+			v.add(path, "duplicate title '%s'", action.Title)
+		}
+		seen[action.Title] = true
+
+		if action.Type == "" {
+			v.add(path+".type", "type is required")
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package moduleconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"coder/internal/envelope"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		support string
+		want    []string
+	}{
+		{
+			name:   "valid config",
+			config: `{"createFields":[{"field":"a","label":"A","type":"input","rules":[{"type":"length","max":10}]}],"tableActions":[{"title":"新增","type":"create"}],"listAPI":"/list","columns":2,"layout":{"table":"Content","form":"TitleContent"}}`,
+		},
+		{
+			name:   "fields without code or label and duplicates",
+			config: `{"createFields":[{"label":"A"},{"field":"b"},{"field":"c","label":"C"},{"field":"c","label":"C2"}]}`,
+			want: []string{
+				"createFields[#0].field: field is required",
+				"createFields[b].label: label is required",
+				"createFields[c]: duplicate field 'c'",
+			},
+		},
+		{
+			name:   "unknown component type and rule",
+			config: `{"updateFields":[{"field":"a","label":"A","type":"slider"},{"field":"b","label":"B","type":"number","rules":[{"type":"email"}]}]}`,
+			want: []string{
+				"updateFields[a].type: unknown component type 'slider'",
+				"updateFields[b].rules: rule 'email' does not apply to component type 'number', only to input, textarea, password, editor",
+			},
+		},
+		{
+			name:    "component type used in support is known",
+			config:  `{"createFields":[{"field":"a","label":"A","type":"slider"}]}`,
+			support: `{"createFields":[{"field":"a","type":"slider"}]}`,
+		},
+		{
+			name:    "field not in support",
+			config:  `{"searchFields":[{"field":"a","label":"A"},{"field":"x","label":"X"}]}`,
+			support: `{"searchFields":[{"field":"a"}]}`,
+			want:    []string{"searchFields[x]: field 'x' is not supported in searchFields"},
+		},
+		{
+			name:   "actions without title or type and duplicates",
+			config: `{"tableOperation":[{"type":"edit"},{"title":"删除"},{"title":"编辑","type":"edit"},{"title":"编辑","type":"edit"}]}`,
+			want: []string{
+				"tableOperation[#0].title: title is required",
+				"tableOperation[删除].type: type is required",
+				"tableOperation[编辑]: duplicate title '编辑'",
+			},
+		},
+		{
+			name:   "relative API URL",
+			config: `{"listAPI":"/list","createAPI":"create","getAPI":"https://example.com/get"}`,
+			want:   []string{"createAPI: URL 'create' must start with '/', 'http://' or 'https://'"},
+		},
+		{
+			name:   "columns out of range",
+			config: `{"columns":5}`,
+			want:   []string{"columns: must be between 1 and 4, got 5"},
+		},
+		{
+			name:    "layout not allowed",
+			config:  `{"layout":{"table":"Cards","form":"Custom"}}`,
+			support: `{"layout":{"form":"Custom"}}`,
+			want:    []string{"layout.table: layout 'Cards' is not supported, must be one of: Content"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := Validate(mustParse(t, tt.config), mustParse(t, tt.support))
+			got := make([]string, 0, len(issues))
+			for _, issue := range issues {
+				got = append(got, issue.String())
+			}
+			if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("Validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateChange(t *testing.T) {
+	// 服务端已有的问题不阻止无关的修改
+	before := mustParse(t, `{"createFields":[{"field":"a"}],"listAPI":"list"}`)
+
+	tests := []struct {
+		name    string
+		after   string
+		wantErr string
+	}{
+		{
+			name:  "inherited problems are ignored",
+			after: `{"createFields":[{"field":"a"},{"field":"b","label":"B"}],"listAPI":"list"}`,
+		},
+		{
+			name:  "fixing a problem is accepted",
+			after: `{"createFields":[{"field":"a","label":"A"}],"listAPI":"list"}`,
+		},
+		{
+			name:    "new problems are rejected",
+			after:   `{"createFields":[{"field":"a"},{"field":"b"}],"listAPI":"list","columns":0}`,
+			wantErr: "invalid module config: createFields[b].label: label is required; columns: must be between 1 and 4, got 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateChange(before, mustParse(t, tt.after), nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateChange() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateChange() error = %v, want %q", err, tt.wantErr)
+			}
+			if code := envelope.CodeOf(err); code != envelope.CodeInvalidConfig {
+				t.Errorf("ValidateChange() error code = %s, want %s", code, envelope.CodeInvalidConfig)
+			}
+		})
+	}
+}
+
+func TestCheckRule(t *testing.T) {
+	tests := []struct {
+		name          string
+		rule          map[string]interface{}
+		componentType string
+		wantErr       string
+	}{
+		{name: "required applies to every component", rule: map[string]interface{}{"type": "required"}, componentType: "select"},
+		{name: "length with min and max", rule: map[string]interface{}{"type": "length", "min": 1, "max": 10.0}},
+		{name: "unknown component accepts every rule", rule: map[string]interface{}{"type": "email"}, componentType: "slider"},
+		{name: "missing type", rule: map[string]interface{}{"message": "x"}, wantErr: "rule type is required"},
+		{name: "unknown type", rule: map[string]interface{}{"type": "ip"}, wantErr: "unknown rule type 'ip'"},
+		{name: "unknown parameter", rule: map[string]interface{}{"type": "email", "max": 1}, wantErr: "rule 'email' has no parameter 'max'"},
+		{name: "message not a string", rule: map[string]interface{}{"type": "required", "message": 1}, wantErr: "message must be a string"},
+		{name: "length without bounds", rule: map[string]interface{}{"type": "length"}, wantErr: "needs at least one of len, min, max"},
+		{name: "length not an integer", rule: map[string]interface{}{"type": "length", "max": 1.5}, wantErr: "must be a non-negative integer"},
+		{name: "length with len and min", rule: map[string]interface{}{"type": "length", "len": 3, "min": 1}, wantErr: "takes either len or min/max"},
+		{name: "range min above max", rule: map[string]interface{}{"type": "range", "min": 5, "max": 1}, componentType: "number", wantErr: "min 5 is greater than max 1"},
+		{name: "invalid pattern", rule: map[string]interface{}{"type": "pattern", "pattern": "("}, wantErr: "invalid regular expression"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckRule(tt.rule, tt.componentType)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckRule() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckRule() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
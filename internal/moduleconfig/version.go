@@ -0,0 +1,33 @@
+package moduleconfig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// HashConfig returns a content hash of a module config that ignores key order and formatting
+func HashConfig(config string) string {
+	if config == "" {
+		return ""
+	}
+	// 先解析再序列化，避免格式差异导致哈希不同
+	canonical := []byte(config)
+	var value interface{}
+	if err := json.Unmarshal(canonical, &value); err == nil {
+		if data, err := json.Marshal(value); err == nil {
+			canonical = data
+		}
+	}
+	sum := sha256.Sum256(canonical)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// LoadedVersion returns the version a draft was loaded at: the version reported by the config service, or the
+// hash of the loaded configuration when the service reported none
+func LoadedVersion(version, original string) string {
+	if version != "" {
+		return version
+	}
+	return HashConfig(original)
+}
@@ -0,0 +1,282 @@
+package moduleops
+
+import (
+	"coder/internal/moduleconfig"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SectionAPIs is the section name under which API changes are reported
+const SectionAPIs = "APIs"
+
+// ModuleDiff is the difference between the saved and the draft configuration of a module
+type ModuleDiff struct {
+	Changed  bool          `json:"changed"`
+	Sections []SectionDiff `json:"sections"` // Only sections that changed
+	Summary  []string      `json:"summary"`  // Human readable description of every change
+}
+
+// SectionDiff describes the changes of one section
+type SectionDiff struct {
+	Section   string        `json:"section"`
+	Added     []interface{} `json:"added,omitempty"`
+	Removed   []interface{} `json:"removed,omitempty"`
+	Modified  []ItemChange  `json:"modified,omitempty"`
+	Reordered bool          `json:"reordered,omitempty"`
+}
+
+// ItemChange describes an item present on both sides whose content changed
+type ItemChange struct {
+	Key        string      `json:"key"`
+	Properties []string    `json:"properties"` // Changed properties of the item
+	Before     interface{} `json:"before"`
+	After      interface{} `json:"after"`
+}
+
+// Diff compares two module configurations given as JSON; an empty before means the module has never been saved
+func Diff(before, after string) (*ModuleDiff, error) {
+	beforeMap, err := decodeConfig(before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse saved module config: %w", err)
+	}
+	afterMap, err := decodeConfig(after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse draft module config: %w", err)
+	}
+	return DiffConfigs(beforeMap, afterMap), nil
+}
+
+// DiffConfigs compares two decoded module configurations
+func DiffConfigs(before, after map[string]interface{}) *ModuleDiff {
+	diff := &ModuleDiff{Sections: []SectionDiff{}, Summary: []string{}}
+	handled := make(map[string]bool)
+
+	for _, section := range moduleconfig.ListSections() {
+		handled[section.Name] = true
+		sd := diffList(section.Name, section.Key, toList(before[section.Name]), toList(after[section.Name]))
+		diff.add(sd)
+	}
+
+	// API 地址按类型比较
+	apis := SectionDiff{Section: SectionAPIs}
+	for _, key := range moduleconfig.APIKeys {
+		handled[key] = true
+		old, hasOld := before[key]
+		cur, hasCur := after[key]
+		switch {
+		case !hasOld && hasCur:
+			apis.Added = append(apis.Added, map[string]interface{}{"type": key, "url": cur})
+		case hasOld && !hasCur:
+			apis.Removed = append(apis.Removed, map[string]interface{}{"type": key, "url": old})
+		case hasOld && hasCur && !reflect.DeepEqual(old, cur):
+			apis.Modified = append(apis.Modified, ItemChange{Key: key, Properties: []string{"url"}, Before: old, After: cur})
+		}
+	}
+	diff.add(apis)
+
+	// 其余配置项（页面名称、布局等）整体比较
+	others := make(map[string]bool)
+	for key := range before {
+		others[key] = true
+	}
+	for key := range after {
+		others[key] = true
+	}
+	keys := make([]string, 0, len(others))
+	for key := range others {
+		if !handled[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		old, hasOld := before[key]
+		cur, hasCur := after[key]
+		sd := SectionDiff{Section: key}
+		switch {
+		case !hasOld:
+			sd.Added = []interface{}{cur}
+		case !hasCur:
+			sd.Removed = []interface{}{old}
+		case !reflect.DeepEqual(old, cur):
+			sd.Modified = []ItemChange{{Key: key, Properties: changedProperties(old, cur), Before: old, After: cur}}
+		}
+		diff.add(sd)
+	}
+
+	return diff
+}
+
+// add records a section diff when it contains changes
+func (d *ModuleDiff) add(sd SectionDiff) {
+	if len(sd.Added) == 0 && len(sd.Removed) == 0 && len(sd.Modified) == 0 && !sd.Reordered {
+		return
+	}
+	d.Changed = true
+	d.Sections = append(d.Sections, sd)
+	d.Summary = append(d.Summary, sd.summary()...)
+}
+
+// summary describes the changes of a section in readable lines
+func (sd SectionDiff) summary() []string {
+	var lines []string
+	for _, item := range sd.Added {
+		lines = append(lines, fmt.Sprintf("%s: added %s", sd.Section, describe(item)))
+	}
+	for _, item := range sd.Removed {
+		lines = append(lines, fmt.Sprintf("%s: removed %s", sd.Section, describe(item)))
+	}
+	for _, change := range sd.Modified {
+		lines = append(lines, fmt.Sprintf("%s: changed %s of '%s'", sd.Section, strings.Join(change.Properties, ", "), change.Key))
+	}
+	if sd.Reordered {
+		lines = append(lines, fmt.Sprintf("%s: order changed", sd.Section))
+	}
+	return lines
+}
+
+// diffList compares two lists whose items are identified by the given property
+func diffList(section, key string, before, after []interface{}) SectionDiff {
+	sd := SectionDiff{Section: section}
+	beforeKeys, beforeItems := indexItems(before, key)
+	afterKeys, afterItems := indexItems(after, key)
+
+	for _, k := range afterKeys {
+		old, ok := beforeItems[k]
+		if !ok {
+			sd.Added = append(sd.Added, afterItems[k])
+			continue
+		}
+		if !reflect.DeepEqual(old, afterItems[k]) {
+			sd.Modified = append(sd.Modified, ItemChange{
+				Key:        k,
+				Properties: changedProperties(old, afterItems[k]),
+				Before:     old,
+				After:      afterItems[k],
+			})
+		}
+	}
+	for _, k := range beforeKeys {
+		if _, ok := afterItems[k]; !ok {
+			sd.Removed = append(sd.Removed, beforeItems[k])
+		}
+	}
+
+	// 只比较两边都存在的项的相对顺序
+	var beforeOrder, afterOrder []string
+	for _, k := range beforeKeys {
+		if _, ok := afterItems[k]; ok {
+			beforeOrder = append(beforeOrder, k)
+		}
+	}
+	for _, k := range afterKeys {
+		if _, ok := beforeItems[k]; ok {
+			afterOrder = append(afterOrder, k)
+		}
+	}
+	sd.Reordered = !reflect.DeepEqual(beforeOrder, afterOrder)
+	return sd
+}
+
+// indexItems returns the item keys in order and the items by key; items without a key are keyed by content
+func indexItems(list []interface{}, key string) ([]string, map[string]interface{}) {
+	keys := make([]string, 0, len(list))
+	items := make(map[string]interface{}, len(list))
+	for _, item := range list {
+		k := ""
+		if m, ok := item.(map[string]interface{}); ok {
+			k, _ = m[key].(string)
+		}
+		if k == "" {
+			// 没有标识的项按内容匹配，插入或删除其他项不会让各方错位
+			k = contentKey(item)
+		}
+		// 重复的项按出现次数区分
+		for base, n := k, 2; ; n++ {
+			if _, exists := items[k]; !exists {
+				break
+			}
+			k = fmt.Sprintf("%s#%d", base, n)
+		}
+		keys = append(keys, k)
+		items[k] = item
+	}
+	return keys, items
+}
+
+// contentKey identifies an item without a key property by a hash of its content
+func contentKey(item interface{}) string {
+	// encoding/json 按键排序输出对象，相同内容得到相同的键
+	data, _ := json.Marshal(item)
+	sum := sha256.Sum256(data)
+	return "#" + hex.EncodeToString(sum[:4])
+}
+
+// changedProperties lists the top level properties that differ between two objects
+func changedProperties(before, after interface{}) []string {
+	beforeMap, ok1 := before.(map[string]interface{})
+	afterMap, ok2 := after.(map[string]interface{})
+	if !ok1 || !ok2 {
+		return []string{"value"}
+	}
+	var props []string
+	for key, value := range afterMap {
+		if old, ok := beforeMap[key]; !ok || !reflect.DeepEqual(old, value) {
+			props = append(props, key)
+		}
+	}
+	for key := range beforeMap {
+		if _, ok := afterMap[key]; !ok {
+			props = append(props, key)
+		}
+	}
+	sort.Strings(props)
+	return props
+}
+
+// describe names an item by its field or title and label
+func describe(item interface{}) string {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		data, _ := json.Marshal(item)
+		return string(data)
+	}
+	if t, ok := m["type"].(string); ok && m["url"] != nil {
+		return fmt.Sprintf("%s '%v'", t, m["url"])
+	}
+	name, _ := m["field"].(string)
+	if name == "" {
+		name, _ = m["title"].(string)
+	}
+	if label, ok := m["label"].(string); ok && label != "" && label != name {
+		return fmt.Sprintf("'%s' (%s)", name, label)
+	}
+	if name != "" {
+		return fmt.Sprintf("'%s'", name)
+	}
+	data, _ := json.Marshal(item)
+	return string(data)
+}
+
+// decodeConfig decodes a module configuration; an empty configuration decodes to an empty map
+func decodeConfig(config string) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	if strings.TrimSpace(config) == "" {
+		return result, nil
+	}
+	if err := json.Unmarshal([]byte(config), &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// toList returns value as a list, or nil when it is not one
+func toList(value interface{}) []interface{} {
+	list, _ := value.([]interface{})
+	return list
+}
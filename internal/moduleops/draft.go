@@ -0,0 +1,20 @@
+package moduleops
+
+import (
+	"coder/internal/cache"
+	"coder/internal/envelope"
+)
+
+// DiffDraft compares a module draft of a conversation, or its active module when the code is empty, with the
+// configuration it was loaded from
+func DiffDraft(sessionID, moduleCode string) (*cache.ModuleCacheData, *ModuleDiff, error) {
+	_, infoCache, err := cache.GetModuleDraft(sessionID, moduleCode)
+	if err != nil {
+		return nil, nil, err
+	}
+	diff, err := Diff(infoCache.Original, infoCache.Cur)
+	if err != nil {
+		return nil, nil, envelope.Errorf(envelope.CodeInvalidConfig, "failed to diff module: %w", err)
+	}
+	return infoCache, diff, nil
+}
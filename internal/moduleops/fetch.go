@@ -0,0 +1,91 @@
+package moduleops
+
+import (
+	"coder/app"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+// APIResponse is the standardized API response structure
+type APIResponse struct {
+	Code int         `json:"code"`
+	Msg  string      `json:"msg"`
+	Data interface{} `json:"data"`
+}
+
+// ModuleConfigData is the data structure for GetModuleConfig response
+type ModuleConfigData struct {
+	Support string `json:"support"`
+	Cur     string `json:"cur"`
+	Version string `json:"version,omitempty"` // Set when the config service versions module configs
+}
+
+// ConfigVersion returns the version of the saved module config, hashing it when the config service reports none
+func ConfigVersion(data *ModuleConfigData) string {
+	return moduleconfig.LoadedVersion(data.Version, data.Cur)
+}
+
+// FetchModuleConfig loads the configuration of a module from the config service
+func FetchModuleConfig(ctx context.Context, moduleName, moduleCode string) (*ModuleConfigData, error) {
+	// Build the request URL with query parameters
+	log.Printf("app.ConfigClient.BaseURL: %v", app.ConfigClient.BaseURL)
+	reqURL := fmt.Sprintf("%s/dynamicForm/config", app.ConfigClient.BaseURL)
+
+	// Create a URL with query parameters
+	baseURL, err := url.Parse(reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	log.Printf("baseURL: %v", baseURL)
+	// Add query parameters
+	query := baseURL.Query()
+	query.Add("moduleName", moduleName)
+	query.Add("moduleCode", moduleCode)
+	baseURL.RawQuery = query.Encode()
+	log.Printf("baseURL.String(): %v", baseURL.String())
+	// Create a new request
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Send the request
+	resp, err := app.ConfigClient.Client.Do(req)
+	if err != nil {
+		return nil, envelope.Errorf(envelope.CodeUpstream, "failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Read the response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// Parse the response
+	var apiResp APIResponse
+	apiResp.Data = &ModuleConfigData{}
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	// Check the response code
+	if apiResp.Code != 200 {
+		return nil, envelope.Errorf(envelope.CodeUpstream, "API error: %s", apiResp.Msg)
+	}
+
+	// Convert the data to the expected structure
+	respData, ok := apiResp.Data.(*ModuleConfigData)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response data format")
+	}
+
+	return respData, nil
+}
@@ -0,0 +1,210 @@
+package moduleops
+
+import (
+	"coder/internal/moduleconfig"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Merge preferences for items changed differently on both sides
+const (
+	PreferServer = "server"
+	PreferDraft  = "draft"
+)
+
+// MergeResult is the three-way merge of a module draft with a concurrently changed server configuration
+type MergeResult struct {
+	Merged    map[string]interface{} `json:"merged"`
+	Conflicts []MergeConflict        `json:"conflicts"`
+}
+
+// MergeConflict is an item changed differently in the draft and on the server
+type MergeConflict struct {
+	Section string      `json:"section"`
+	Key     string      `json:"key"`
+	Base    interface{} `json:"base"`   // Value when the draft was loaded, nil when absent
+	Draft   interface{} `json:"draft"`  // Value in the draft, nil when removed
+	Server  interface{} `json:"server"` // Value on the server, nil when removed
+}
+
+// Merge merges the changes made in the draft since base into the server configuration; conflicting items
+// take the value of the preferred side
+func Merge(base, draft, server map[string]interface{}, prefer string) *MergeResult {
+	result := &MergeResult{Merged: make(map[string]interface{}), Conflicts: []MergeConflict{}}
+	handled := make(map[string]bool)
+
+	for _, section := range moduleconfig.ListSections() {
+		handled[section.Name] = true
+		_, inDraft := draft[section.Name]
+		_, inServer := server[section.Name]
+		if !inDraft && !inServer {
+			continue
+		}
+		result.Merged[section.Name] = result.mergeList(section.Name, section.Key,
+			toList(base[section.Name]), toList(draft[section.Name]), toList(server[section.Name]), prefer)
+	}
+
+	// 其余配置项（API、页面名称、布局等）按键合并
+	keys := make(map[string]bool)
+	for _, m := range []map[string]interface{}{base, draft, server} {
+		for key := range m {
+			if !handled[key] {
+				keys[key] = true
+			}
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	for _, key := range sorted {
+		section := key
+		for _, api := range moduleconfig.APIKeys {
+			if api == key {
+				section = SectionAPIs
+			}
+		}
+		b, hasB := base[key]
+		d, hasD := draft[key]
+		s, hasS := server[key]
+		if value, ok := result.mergeValue(section, key, b, hasB, d, hasD, s, hasS, prefer); ok {
+			result.Merged[key] = value
+		}
+	}
+
+	return result
+}
+
+// MergeConfigs merges module configurations given as JSON, see Merge
+func MergeConfigs(base, draft, server, prefer string) (*MergeResult, error) {
+	baseMap, err := decodeConfig(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base module config: %w", err)
+	}
+	draftMap, err := decodeConfig(draft)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse draft module config: %w", err)
+	}
+	serverMap, err := decodeConfig(server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse server module config: %w", err)
+	}
+	return Merge(baseMap, draftMap, serverMap, prefer), nil
+}
+
+// orderKey is the conflict key reported when both sides reordered a list section differently
+const orderKey = "(order)"
+
+// mergeList merges a keyed list section. The order of the side that reordered the common items is kept, the server
+// order when neither or both did the same; when both reordered differently it is a conflict resolved by prefer.
+// Items added on the other side are placed after the item they follow there.
+func (r *MergeResult) mergeList(section, key string, base, draft, server []interface{}, prefer string) []interface{} {
+	baseKeys, baseItems := indexItems(base, key)
+	draftKeys, draftItems := indexItems(draft, key)
+	serverKeys, serverItems := indexItems(server, key)
+
+	// 只比较双方都保留的项的相对顺序，新增和删除不算调整顺序
+	draftReordered := !equalKeys(keep(draftKeys, baseItems), keep(baseKeys, draftItems))
+	serverReordered := !equalKeys(keep(serverKeys, baseItems), keep(baseKeys, serverItems))
+	draftFirst := draftReordered && !serverReordered
+	if draftReordered && serverReordered {
+		common := keep(keep(baseKeys, draftItems), serverItems)
+		if !equalKeys(keep(draftKeys, toSet(common)), keep(serverKeys, toSet(common))) {
+			r.Conflicts = append(r.Conflicts, MergeConflict{Section: section, Key: orderKey, Base: baseKeys, Draft: draftKeys, Server: serverKeys})
+			draftFirst = prefer == PreferDraft
+		}
+	}
+
+	var order []string
+	if draftFirst {
+		order = weave(draftKeys, serverKeys)
+	} else {
+		order = weave(serverKeys, draftKeys)
+	}
+	merged := make([]interface{}, 0, len(order))
+	for _, k := range order {
+		b, hasB := baseItems[k]
+		d, hasD := draftItems[k]
+		s, hasS := serverItems[k]
+		if value, ok := r.mergeValue(section, k, b, hasB, d, hasD, s, hasS, prefer); ok {
+			merged = append(merged, value)
+		}
+	}
+	return merged
+}
+
+// weave returns primary with the keys only in secondary inserted after the key they follow in secondary
+func weave(primary, secondary []string) []string {
+	order := append([]string{}, primary...)
+	present := toSet(primary)
+	for i, k := range secondary {
+		if present[k] {
+			continue
+		}
+		// 找到该项在另一方中前面最近的已排好的项，插在其后；没有时放在最前
+		at := 0
+		for j := i - 1; j >= 0; j-- {
+			if present[secondary[j]] {
+				at = indexOf(order, secondary[j]) + 1
+				break
+			}
+		}
+		order = append(order[:at], append([]string{k}, order[at:]...)...)
+		present[k] = true
+	}
+	return order
+}
+
+// keep returns the keys that are in set, in order
+func keep[T any](keys []string, set map[string]T) []string {
+	kept := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if _, ok := set[k]; ok {
+			kept = append(kept, k)
+		}
+	}
+	return kept
+}
+
+func toSet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
+
+func equalKeys(a, b []string) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func indexOf(keys []string, key string) int {
+	for i, k := range keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeValue merges one value present on any side; ok is false when the merged result removes it
+func (r *MergeResult) mergeValue(section, key string, b interface{}, hasB bool, d interface{}, hasD bool, s interface{}, hasS bool, prefer string) (interface{}, bool) {
+	draftChanged := hasD != hasB || !reflect.DeepEqual(d, b)
+	serverChanged := hasS != hasB || !reflect.DeepEqual(s, b)
+	switch {
+	case !draftChanged:
+		return s, hasS
+	case !serverChanged:
+		return d, hasD
+	case hasD == hasS && reflect.DeepEqual(d, s):
+		return d, hasD
+	}
+
+	r.Conflicts = append(r.Conflicts, MergeConflict{Section: section, Key: key, Base: b, Draft: d, Server: s})
+	if prefer == PreferDraft {
+		return d, hasD
+	}
+	return s, hasS
+}
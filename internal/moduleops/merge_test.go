@@ -0,0 +1,119 @@
+package moduleops
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeConfigs(t *testing.T) {
+	tests := []struct {
+		name          string
+		base          string
+		draft         string
+		server        string
+		prefer        string
+		want          string
+		wantConflicts []string
+	}{
+		{
+			name:   "disjoint edits",
+			base:   `{"createFields":[{"field":"a","label":"A"},{"field":"b","label":"B"}],"listAPI":"/list"}`,
+			draft:  `{"createFields":[{"field":"a","label":"A2"},{"field":"b","label":"B"}],"listAPI":"/list"}`,
+			server: `{"createFields":[{"field":"a","label":"A"},{"field":"b","label":"B2"}],"listAPI":"/list2"}`,
+			prefer: PreferServer,
+			want:   `{"createFields":[{"field":"a","label":"A2"},{"field":"b","label":"B2"}],"listAPI":"/list2"}`,
+		},
+		{
+			name:          "same key edited on both sides prefers server",
+			base:          `{"createFields":[{"field":"a","label":"A"}]}`,
+			draft:         `{"createFields":[{"field":"a","label":"draft"}]}`,
+			server:        `{"createFields":[{"field":"a","label":"server"}]}`,
+			prefer:        PreferServer,
+			want:          `{"createFields":[{"field":"a","label":"server"}]}`,
+			wantConflicts: []string{"createFields/a"},
+		},
+		{
+			name:          "same key edited on both sides prefers draft",
+			base:          `{"listAPI":"/list"}`,
+			draft:         `{"listAPI":"/draft"}`,
+			server:        `{"listAPI":"/server"}`,
+			prefer:        PreferDraft,
+			want:          `{"listAPI":"/draft"}`,
+			wantConflicts: []string{SectionAPIs + "/listAPI"},
+		},
+		{
+			name:          "delete in draft against edit on server",
+			base:          `{"createFields":[{"field":"a","label":"A"},{"field":"b","label":"B"}]}`,
+			draft:         `{"createFields":[{"field":"b","label":"B"}]}`,
+			server:        `{"createFields":[{"field":"a","label":"A2"},{"field":"b","label":"B"}]}`,
+			prefer:        PreferDraft,
+			want:          `{"createFields":[{"field":"b","label":"B"}]}`,
+			wantConflicts: []string{"createFields/a"},
+		},
+		{
+			name:   "same delete on both sides",
+			base:   `{"createFields":[{"field":"a"},{"field":"b"}]}`,
+			draft:  `{"createFields":[{"field":"b"}]}`,
+			server: `{"createFields":[{"field":"b"}]}`,
+			prefer: PreferServer,
+			want:   `{"createFields":[{"field":"b"}]}`,
+		},
+		{
+			name:   "draft reorder kept with a server addition",
+			base:   `{"tableFields":[{"field":"a"},{"field":"b"},{"field":"c"}]}`,
+			draft:  `{"tableFields":[{"field":"c"},{"field":"a"},{"field":"b"}]}`,
+			server: `{"tableFields":[{"field":"a"},{"field":"b"},{"field":"c"},{"field":"d"}]}`,
+			prefer: PreferServer,
+			want:   `{"tableFields":[{"field":"c"},{"field":"d"},{"field":"a"},{"field":"b"}]}`,
+		},
+		{
+			name:   "server reorder kept with a draft addition",
+			base:   `{"tableFields":[{"field":"a"},{"field":"b"}]}`,
+			draft:  `{"tableFields":[{"field":"a"},{"field":"x"},{"field":"b"}]}`,
+			server: `{"tableFields":[{"field":"b"},{"field":"a"}]}`,
+			prefer: PreferDraft,
+			want:   `{"tableFields":[{"field":"b"},{"field":"a"},{"field":"x"}]}`,
+		},
+		{
+			name:          "both reordered differently",
+			base:          `{"tableFields":[{"field":"a"},{"field":"b"},{"field":"c"}]}`,
+			draft:         `{"tableFields":[{"field":"c"},{"field":"b"},{"field":"a"}]}`,
+			server:        `{"tableFields":[{"field":"b"},{"field":"a"},{"field":"c"}]}`,
+			prefer:        PreferDraft,
+			want:          `{"tableFields":[{"field":"c"},{"field":"b"},{"field":"a"}]}`,
+			wantConflicts: []string{"tableFields/" + orderKey},
+		},
+		{
+			name:   "items without key matched by content",
+			base:   `{"tableActions":[{"type":"export"}]}`,
+			draft:  `{"tableActions":[{"type":"export"},{"title":"新增"}]}`,
+			server: `{"tableActions":[{"type":"export"}]}`,
+			prefer: PreferServer,
+			want:   `{"tableActions":[{"type":"export"},{"title":"新增"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergeConfigs(tt.base, tt.draft, tt.server, tt.prefer)
+			if err != nil {
+				t.Fatalf("MergeConfigs() error = %v", err)
+			}
+			want, err := decodeConfig(tt.want)
+			if err != nil {
+				t.Fatalf("invalid want: %v", err)
+			}
+			if !reflect.DeepEqual(got.Merged, want) {
+				t.Errorf("MergeConfigs() merged = %v, want %v", got.Merged, want)
+			}
+
+			conflicts := make([]string, 0, len(got.Conflicts))
+			for _, c := range got.Conflicts {
+				conflicts = append(conflicts, c.Section+"/"+c.Key)
+			}
+			if len(conflicts) != len(tt.wantConflicts) || (len(conflicts) > 0 && !reflect.DeepEqual(conflicts, tt.wantConflicts)) {
+				t.Errorf("MergeConfigs() conflicts = %v, want %v", conflicts, tt.wantConflicts)
+			}
+		})
+	}
+}
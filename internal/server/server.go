@@ -10,13 +10,16 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 
 	"coder/app"
 	"coder/internal/agent"
+	"coder/internal/cache"
 	"coder/internal/handler"
+	"coder/internal/moduleconfig"
 )
 
 // Server represents the HTTP server
@@ -29,6 +32,14 @@ type Server struct {
 
 // New creates a new server
 func New(ctx context.Context, staticFiles fs.FS) (*Server, error) {
+	// Initialize draft store
+	if err := cache.Init(app.Config.Cache.Backend, app.Config.Cache.Path, time.Duration(app.Config.Cache.DraftTTL)*time.Hour); err != nil {
+		return nil, fmt.Errorf("failed to initialize draft store: %w", err)
+	}
+
+	// 工具描述中列出可用的页面布局，需在创建工具前设置
+	moduleconfig.SetLayoutValues(app.Config.Module.Layouts)
+
 	// Create agent
 	agent, err := agent.New(ctx)
 	if err != nil {
@@ -77,6 +88,12 @@ func (s *Server) SetupRoutes() {
 	api := s.ginEngine.Group("/api")
 	{
 		api.GET("/health", s.handler.HandleHealthCheck)
+
+		// 模块草稿修改记录
+		api.GET("/conversations/:id/changes", s.handler.HandleListChanges)
+		api.POST("/conversations/:id/changes/undo", s.handler.HandleUndoChange)
+		api.POST("/conversations/:id/changes/redo", s.handler.HandleRedoChange)
+		api.GET("/conversations/:id/diff", s.handler.HandleDiffModule)
 	}
 
 	// OpenAI-compatible chat completions endpoint
@@ -171,4 +188,7 @@ func (s *Server) Cleanup() {
 	if s.agent != nil {
 		s.agent.Close()
 	}
+
+	// Close draft store
+	cache.Close()
 }
@@ -2,6 +2,8 @@ package addaction
 
 import (
 	"coder/internal/cache"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -27,6 +29,11 @@ func (t *AddActionTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 		Name: "addAction",
 		Desc: "Add an action to a module",
 		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to edit, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
 			"title": {
 				Desc:     "The title of the action (e.g., '添加')",
 				Type:     schema.String,
@@ -65,80 +72,49 @@ func (t *AddActionTool) IsInvokable() bool {
 func (t *AddActionTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
 	// 解析输入参数
 	var params struct {
-		Title   string                 `json:"title"`
-		Type    string                 `json:"type"`
-		Options map[string]interface{} `json:"options"`
+		ModuleCode string                 `json:"module_code"`
+		Title      string                 `json:"title"`
+		Type       string                 `json:"type"`
+		Options    map[string]interface{} `json:"options"`
 	}
 
 	// 将JSON字符串反序列化为结构体
 	if err := json.Unmarshal([]byte(args), &params); err != nil {
-		return "", fmt.Errorf("failed to parse arguments: %w", err)
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
 	}
 
 	// 验证必填参数
 	if params.Title == "" {
-		return "", fmt.Errorf("title cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "title cannot be empty")
 	}
 	if params.Type == "" {
-		return "", fmt.Errorf("type cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "type cannot be empty")
 	}
 	if params.Options == nil {
-		return "", fmt.Errorf("options cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "options cannot be empty")
 	}
 
 	// 从上下文中解码模块信息
-	infoCache, mapCur, _, userReq, err := cache.DecodeModuleFromCtx(ctx)
+	infoCache, cur, _, userReq, err := cache.DecodeModuleFromCtx(ctx, params.ModuleCode)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode module from context: %w", err)
 	}
 
-	// 获取当前模块的tableActions，如果不存在则初始化
-	tableAction, ok := mapCur["tableActions"].([]interface{})
-	if !ok {
-		tableAction = make([]interface{}, 0)
-	}
-
-	// 创建title到index的映射，用于快速查找已存在的action
-	title2Index := make(map[string]int)
-	for index, action := range tableAction {
-		actionMap := action.(map[string]interface{})
-		if title, ok := actionMap["title"].(string); ok {
-			title2Index[title] = index
-		}
-	}
-
 	// 如果action已存在则更新，否则添加新action
-	if index, ok := title2Index[params.Title]; ok {
-		tableAction[index] = map[string]interface{}{
-			"title":   params.Title,
-			"type":    params.Type,
-			"options": params.Options,
-		}
+	action := moduleconfig.Action{Title: params.Title, Type: params.Type, Options: params.Options}
+	if index := moduleconfig.ActionIndex(cur.TableActions, params.Title); index >= 0 {
+		cur.TableActions[index] = action
 	} else {
-		tableAction = append(tableAction, map[string]interface{}{
-			"title":   params.Title,
-			"type":    params.Type,
-			"options": params.Options,
-		})
+		cur.TableActions = append(cur.TableActions, action)
 	}
-	mapCur["tableActions"] = tableAction
 
-	// 将修改后的模块信息序列化并保存到缓存
-	jsonCur, _ := json.Marshal(mapCur)
-	moduleCache := cache.NewModuleCacheData(infoCache.ModuleName, infoCache.ModuleCode, infoCache.Support, string(jsonCur))
-	cache.ModuleCacheInstance.Set(cache.CacheKey(userReq.ConversationID), moduleCache, cache.DefaultCacheExpiration)
-
-	// 构造并返回成功响应
-	mockResponse := map[string]interface{}{
-		"success": true,
-		"message": fmt.Sprintf("Action for range '%s' (%s) has been added successfully with %d actions",
-			params.Title, params.Type, len(params.Options)),
-		"params": params,
-	}
-	result, err := json.MarshalIndent(mockResponse, "", "  ")
-	if err != nil {
-		return "", fmt.Errorf("failed to format response: %w", err)
+	// 校验修改后的模块配置并保存到缓存
+	message := fmt.Sprintf("Action for range '%s' (%s) has been added successfully with %d actions",
+		params.Title, params.Type, len(params.Options))
+	if err := cache.CommitModuleConfig(ctx, userReq.ConversationID, infoCache, cur, "addAction", message); err != nil {
+		return "", err
 	}
 
-	return string(result), nil
+	// 构造并返回成功响应
+	return envelope.OK(message, params)
 }
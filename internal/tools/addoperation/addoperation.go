@@ -4,6 +4,8 @@ import (
 	"coder/api"
 	"coder/internal/cache"
 	"coder/internal/config"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -34,6 +36,11 @@ func (t *AddOperationTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 		Name: "addOperation",
 		Desc: "Add an operation to a module",
 		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to edit, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
 			"title": {
 				Desc:     "The title of the operation (e.g., '详情')",
 				Type:     schema.String,
@@ -79,25 +86,26 @@ func (t *AddOperationTool) InvokableRun(ctx context.Context, args string, _ ...t
 	// 解析输入参数
 	// 将JSON字符串反序列化为结构体，包含title, type和options字段
 	var params struct {
-		Title   string                 `json:"title"`
-		Type    string                 `json:"type"`
-		Options map[string]interface{} `json:"options"`
+		ModuleCode string                 `json:"module_code"`
+		Title      string                 `json:"title"`
+		Type       string                 `json:"type"`
+		Options    map[string]interface{} `json:"options"`
 	}
 
 	if err := json.Unmarshal([]byte(args), &params); err != nil {
-		return "", fmt.Errorf("failed to parse arguments: %w", err)
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
 	}
 
 	// 验证必填参数
 	// 确保title, type和options都不为空，否则返回错误
 	if params.Title == "" {
-		return "", fmt.Errorf("title cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "title cannot be empty")
 	}
 	if params.Type == "" {
-		return "", fmt.Errorf("type cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "type cannot be empty")
 	}
 	if params.Options == nil {
-		return "", fmt.Errorf("options cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "options cannot be empty")
 	}
 
 	// 在实际实现中，我们将操作添加到模块中
@@ -110,52 +118,28 @@ func (t *AddOperationTool) InvokableRun(ctx context.Context, args string, _ ...t
 
 	// 从上下文中解码模块信息
 	// 获取模块缓存数据和当前模块状态
-	infoCache, mapCur, _, userReq, err := cache.DecodeModuleFromCtx(ctx)
+	infoCache, cur, _, userReq, err := cache.DecodeModuleFromCtx(ctx, params.ModuleCode)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode module from context: %w", err)
 	}
 
-	tableOperation := mapCur["tableOperation"].([]interface{})
-	// 创建title到index的映射
-	// 用于快速查找已存在的操作
-	title2Index := make(map[string]int)
-	for index, field := range tableOperation {
-		title2Index[field.(map[string]interface{})["title"].(string)] = index
-	}
 	// 更新或添加操作
 	// 如果操作已存在则更新，否则添加新操作
-	if index, ok := title2Index[params.Title]; ok {
-		tableOperation[index] = map[string]interface{}{
-			"title":   params.Title,
-			"type":    params.Type,
-			"options": params.Options,
-		}
+	operation := moduleconfig.Action{Title: params.Title, Type: params.Type, Options: params.Options}
+	if index := moduleconfig.ActionIndex(cur.TableOperation, params.Title); index >= 0 {
+		cur.TableOperation[index] = operation
 	} else {
-		tableOperation = append(tableOperation, map[string]interface{}{
-			"title":   params.Title,
-			"type":    params.Type,
-			"options": params.Options,
-		})
+		cur.TableOperation = append(cur.TableOperation, operation)
 	}
-	mapCur["tableOperation"] = tableOperation
 	// Save to cache
 	// 保存到缓存
-	// 将修改后的模块信息序列化并存储到缓存中，设置默认过期时间
-	jsonCur, _ := json.Marshal(mapCur)
-	moduleCache := cache.NewModuleCacheData(infoCache.ModuleName, infoCache.ModuleCode, infoCache.Support, string(jsonCur))
-	cache.ModuleCacheInstance.Set(cache.CacheKey(userReq.ConversationID), moduleCache, cache.DefaultCacheExpiration)
+	// 校验修改后的模块配置，通过后存储到缓存中
+	message := fmt.Sprintf("Operation '%s' has been added successfully", params.Title)
+	if err := cache.CommitModuleConfig(ctx, userReq.ConversationID, infoCache, cur, "addOperation", message); err != nil {
+		return "", err
+	}
 
 	// 格式化响应
 	// 构造成功响应信息，包含操作结果和参数详情
-	mockResponse := map[string]interface{}{
-		"success": true,
-		"message": fmt.Sprintf("Operation '%s' has been added successfully", params.Title),
-		"params":  params,
-	}
-	result, err := json.MarshalIndent(mockResponse, "", "  ")
-	if err != nil {
-		return "", fmt.Errorf("failed to format response: %w", err)
-	}
-
-	return string(result), nil
+	return envelope.OK(message, params)
 }
@@ -0,0 +1,122 @@
+package applymoduleedits
+
+import (
+	"coder/internal/cache"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ApplyModuleEditsTool is a tool for applying several edits to a module draft at once
+type ApplyModuleEditsTool struct{}
+
+// EditResult is the outcome of one edit of the batch
+type EditResult struct {
+	Index   int    `json:"index"`
+	Op      string `json:"op"`
+	Target  string `json:"target"`
+	Status  string `json:"status"` // ok or failed
+	Summary string `json:"summary,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// NewApplyModuleEditsTool creates a new apply module edits tool
+func NewApplyModuleEditsTool() (*ApplyModuleEditsTool, error) {
+	return &ApplyModuleEditsTool{}, nil
+}
+
+// Info returns information about the tool
+func (t *ApplyModuleEditsTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "applyModuleEdits",
+		Desc: "Apply several edits to a module in order, all or none. Use it instead of many single edit tools when the user asks for several changes at once. " +
+			"Each edit has op (add, edit, delete, move) and target (field, action, operation, api). " +
+			"Fields: range_code (view, create, update, list, search) with fields for add/edit, names for delete, field with before/after/index or order for move. " +
+			"Actions and operations: title, type, options, old_title for edit. APIs: type (listAPI, createAPI, getAPI, updateAPI, deleteAPI) and url.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to edit, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
+			"edits": {
+				Desc:     "The edits to apply, in order",
+				Type:     schema.Array,
+				ElemInfo: &schema.ParameterInfo{Type: schema.Object},
+				Required: true,
+			},
+		}),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *ApplyModuleEditsTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *ApplyModuleEditsTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	// Parse the arguments
+	var params struct {
+		ModuleCode string              `json:"module_code"`
+		Edits      []moduleconfig.Edit `json:"edits"`
+	}
+
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+	}
+
+	if len(params.Edits) == 0 {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "edits cannot be empty")
+	}
+
+	infoCache, cur, support, userReq, err := cache.DecodeModuleFromCtx(ctx, params.ModuleCode)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode module from context: %w", err)
+	}
+
+	// 依次应用到草稿副本上；失败的修改不影响后续修改，全部成功后才保存
+	results := make([]EditResult, 0, len(params.Edits))
+	summaries := make([]string, 0, len(params.Edits))
+	var firstErr error
+	for index, edit := range params.Edits {
+		result := EditResult{Index: index, Op: edit.Op, Target: edit.Target, Status: "ok"}
+		next, err := cur.Clone()
+		if err != nil {
+			return "", err
+		}
+		summary, err := moduleconfig.Apply(next, support, edit)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			result.Status = "failed"
+			result.Code = envelope.CodeOf(err)
+			result.Error = err.Error()
+		} else {
+			cur = next
+			result.Summary = summary
+			summaries = append(summaries, summary)
+		}
+		results = append(results, result)
+	}
+
+	if firstErr != nil {
+		return "", envelope.ErrorWithData(envelope.CodeOf(firstErr), map[string]interface{}{"results": results},
+			"%d of %d edits failed, none were applied: %v", len(params.Edits)-len(summaries), len(params.Edits), firstErr)
+	}
+
+	message := fmt.Sprintf("%d edits have been applied successfully: %s", len(summaries), strings.Join(summaries, "; "))
+	if err := cache.CommitModuleConfig(ctx, userReq.ConversationID, infoCache, cur, "applyModuleEdits", message); err != nil {
+		return "", err
+	}
+
+	return envelope.OK(message, map[string]interface{}{"results": results})
+}
@@ -0,0 +1,125 @@
+package bindoptionset
+
+import (
+	"coder/internal/cache"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// BindOptionSetTool is a tool for using an option set as the options of a field of a module
+type BindOptionSetTool struct{}
+
+// NewBindOptionSetTool creates a new bind option set tool
+func NewBindOptionSetTool() (*BindOptionSetTool, error) {
+	return &BindOptionSetTool{}, nil
+}
+
+// Info returns information about the tool
+func (t *BindOptionSetTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "bindOptionSet",
+		Desc: "Use an option set as the options of a field of a module in the create, update, search and view ranges, so the field shows the same options everywhere; later edits of the option set update the field",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to edit, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
+			"range_codes": {
+				Desc:     "The ranges to change, among " + strings.Join(moduleconfig.OptionRanges, ", ") + "; defaults to every range whose field can show options",
+				Type:     schema.Array,
+				ElemInfo: &schema.ParameterInfo{Type: schema.String},
+				Required: false,
+			},
+			"field": {
+				Desc:     "The field key of the field",
+				Type:     schema.String,
+				Required: true,
+			},
+			"option_set": {
+				Desc:     "The name of the option set",
+				Type:     schema.String,
+				Required: true,
+			},
+		}),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *BindOptionSetTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *BindOptionSetTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	// Parse the arguments
+	var params struct {
+		ModuleCode string   `json:"module_code"`
+		RangeCodes []string `json:"range_codes"`
+		Field      string   `json:"field"`
+		OptionSet  string   `json:"option_set"`
+	}
+
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+	}
+
+	if params.Field == "" {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "field cannot be empty")
+	}
+	if params.OptionSet == "" {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "option_set cannot be empty")
+	}
+
+	infoCache, cur, _, userReq, err := cache.DecodeModuleFromCtx(ctx, params.ModuleCode)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode module from context: %w", err)
+	}
+	set, err := cache.GetOptionSet(userReq.ConversationID, params.OptionSet)
+	if err != nil {
+		return "", err
+	}
+
+	targets, err := moduleconfig.OptionTargets(cur, params.RangeCodes, params.Field)
+	if err != nil {
+		return "", err
+	}
+	// 未指定范围时跳过不能显示选项的字段（如搜索中的输入框），指定范围时直接报错
+	var sections, skipped []string
+	var firstErr error
+	for _, target := range targets {
+		if err := moduleconfig.BindOptionSet(target.Field, target.Range, set); err != nil {
+			if len(params.RangeCodes) > 0 {
+				return "", err
+			}
+			skipped = append(skipped, err.Error())
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		sections = append(sections, target.Range.Section)
+	}
+	if len(sections) == 0 {
+		return "", firstErr
+	}
+
+	message := fmt.Sprintf("Option set '%s' has been bound to field '%s' in %s", set.Name, params.Field, strings.Join(sections, ", "))
+	if err := cache.CommitModuleConfig(ctx, userReq.ConversationID, infoCache, cur, "bindOptionSet", message); err != nil {
+		return "", err
+	}
+
+	return envelope.OK(message, map[string]interface{}{
+		"field":     params.Field,
+		"optionSet": set,
+		"sections":  sections,
+		"skipped":   skipped,
+	})
+}
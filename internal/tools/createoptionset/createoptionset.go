@@ -0,0 +1,80 @@
+package createoptionset
+
+import (
+	"coder/api"
+	"coder/internal/cache"
+	"coder/internal/config"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// CreateOptionSetTool is a tool for creating a named option set shared by select, radio and checkbox fields
+type CreateOptionSetTool struct{}
+
+// NewCreateOptionSetTool creates a new create option set tool
+func NewCreateOptionSetTool() (*CreateOptionSetTool, error) {
+	return &CreateOptionSetTool{}, nil
+}
+
+// Info returns information about the tool
+func (t *CreateOptionSetTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "createOptionSet",
+		Desc: "Create a named option set (dictionary, e.g. 状态: 启用/停用) in this conversation. Bind it to select, radio and checkbox fields with bindOptionSet or the optionSet attribute of genField so every range shows the same options",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"name": {
+				Desc:     "The name of the option set, such as status",
+				Type:     schema.String,
+				Required: true,
+			},
+			"label": {
+				Desc:     "The Chinese name of the option set, such as 状态",
+				Type:     schema.String,
+				Required: false,
+			},
+			"options": {
+				Desc:     "The options, each with label and value",
+				Type:     schema.Array,
+				ElemInfo: &schema.ParameterInfo{Type: schema.Object},
+				Required: true,
+			},
+		}),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *CreateOptionSetTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *CreateOptionSetTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	// Parse the arguments
+	var set moduleconfig.OptionSet
+	if err := json.Unmarshal([]byte(args), &set); err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+	}
+	if err := moduleconfig.CheckOptionSet(set); err != nil {
+		return "", err
+	}
+
+	userReq, ok := ctx.Value(config.StateKey).(*api.ChatRequest)
+	if !ok {
+		return "", fmt.Errorf("state not found in context")
+	}
+
+	if _, err := cache.GetOptionSet(userReq.ConversationID, set.Name); err == nil {
+		return "", envelope.Errorf(envelope.CodeConflict, "option set '%s' already exists, change it with editOptionSet", set.Name)
+	}
+	if err := cache.SaveOptionSet(ctx, userReq.ConversationID, set); err != nil {
+		return "", err
+	}
+
+	return envelope.OK(fmt.Sprintf("Option set '%s' has been created with %d options", set.Name, len(set.Options)), set)
+}
@@ -2,6 +2,8 @@ package deleteaction
 
 import (
 	"coder/internal/cache"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -24,6 +26,11 @@ func (t *DeleteActionTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 		Name: "deleteAction",
 		Desc: "Delete an action from a module",
 		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to edit, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
 			"title": {
 				Desc:     "The title of the action to delete",
 				Type:     schema.String,
@@ -42,54 +49,37 @@ func (t *DeleteActionTool) IsInvokable() bool {
 func (t *DeleteActionTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
 	// Parse the arguments
 	var params struct {
-		Title string `json:"title"`
+		ModuleCode string `json:"module_code"`
+		Title      string `json:"title"`
 	}
 
 	if err := json.Unmarshal([]byte(args), &params); err != nil {
-		return "", fmt.Errorf("failed to parse arguments: %w", err)
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
 	}
 
 	// Validate required parameters
 	if params.Title == "" {
-		return "", fmt.Errorf("title cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "title cannot be empty")
 	}
 
 	// In a real implementation, we would delete the action from the module
-	infoCache, mapCur, _, userReq, err := cache.DecodeModuleFromCtx(ctx)
+	infoCache, cur, _, userReq, err := cache.DecodeModuleFromCtx(ctx, params.ModuleCode)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode module from context: %w", err)
 	}
 
-	tableAction, ok := mapCur["tableActions"].([]interface{})
-	if !ok {
-		// If tableAction doesn't exist, there's nothing to delete
-		return fmt.Sprintf("No actions found with title: %s", params.Title), nil
-	}
-
-	for index, action := range tableAction {
-		actionMap := action.(map[string]interface{})
-		if title, ok := actionMap["title"].(string); ok && title == params.Title {
-			tableAction = append(tableAction[:index], tableAction[index+1:]...)
-			break
-		}
+	index := moduleconfig.ActionIndex(cur.TableActions, params.Title)
+	if index < 0 {
+		// If the action doesn't exist, there's nothing to delete
+		return envelope.OK(fmt.Sprintf("No actions found with title: %s", params.Title), params)
 	}
-
-	mapCur["tableActions"] = tableAction
+	cur.TableActions = append(cur.TableActions[:index], cur.TableActions[index+1:]...)
 
 	// Save to cache
-	jsonCur, _ := json.Marshal(mapCur)
-	moduleCache := cache.NewModuleCacheData(infoCache.ModuleName, infoCache.ModuleCode, infoCache.Support, string(jsonCur))
-	cache.ModuleCacheInstance.Set(cache.CacheKey(userReq.ConversationID), moduleCache, cache.DefaultCacheExpiration)
-
-	mockResponse := map[string]interface{}{
-		"success": true,
-		"message": fmt.Sprintf("Action '%s' has been deleted successfully", params.Title),
-		"params":  params,
-	}
-	result, err := json.MarshalIndent(mockResponse, "", "  ")
-	if err != nil {
-		return "", fmt.Errorf("failed to format response: %w", err)
+	message := fmt.Sprintf("Action '%s' has been deleted successfully", params.Title)
+	if err := cache.CommitModuleConfig(ctx, userReq.ConversationID, infoCache, cur, "deleteAction", message); err != nil {
+		return "", err
 	}
 
-	return string(result), nil
+	return envelope.OK(message, params)
 }
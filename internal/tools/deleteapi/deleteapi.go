@@ -2,6 +2,7 @@ package deleteapi
 
 import (
 	"coder/internal/cache"
+	"coder/internal/envelope"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -24,6 +25,11 @@ func (t *DeleteAPITool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 		Name: "deleteAPI",
 		Desc: "Delete an API URL from a module",
 		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to edit, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
 			"type": {
 				Desc:     "The type of API to delete (one of: listAPI, createAPI, getAPI, updateAPI, deleteAPI)",
 				Type:     schema.String,
@@ -42,16 +48,17 @@ func (t *DeleteAPITool) IsInvokable() bool {
 func (t *DeleteAPITool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
 	// Parse the arguments
 	var params struct {
-		Type string `json:"type"`
+		ModuleCode string `json:"module_code"`
+		Type       string `json:"type"`
 	}
 
 	if err := json.Unmarshal([]byte(args), &params); err != nil {
-		return "", fmt.Errorf("failed to parse arguments: %w", err)
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
 	}
 
 	// Validate required parameters
 	if params.Type == "" {
-		return "", fmt.Errorf("type cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "type cannot be empty")
 	}
 
 	// Validate type is one of the allowed values
@@ -63,32 +70,23 @@ func (t *DeleteAPITool) InvokableRun(ctx context.Context, args string, _ ...tool
 		"deleteAPI": true,
 	}
 	if !validTypes[params.Type] {
-		return "", fmt.Errorf("invalid type: %s. Must be one of: listAPI, createAPI, getAPI, updateAPI, deleteAPI", params.Type)
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "invalid type: %s. Must be one of: listAPI, createAPI, getAPI, updateAPI, deleteAPI", params.Type)
 	}
 
 	// Decode module from context
-	infoCache, mapCur, _, userReq, err := cache.DecodeModuleFromCtx(ctx)
+	infoCache, cur, _, userReq, err := cache.DecodeModuleFromCtx(ctx, params.ModuleCode)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode module from context: %w", err)
 	}
 
 	// Delete the API URL
-	delete(mapCur, params.Type)
+	*cur.API(params.Type) = ""
 
 	// Save to cache
-	jsonCur, _ := json.Marshal(mapCur)
-	moduleCache := cache.NewModuleCacheData(infoCache.ModuleName, infoCache.ModuleCode, infoCache.Support, string(jsonCur))
-	cache.ModuleCacheInstance.Set(cache.CacheKey(userReq.ConversationID), moduleCache, cache.DefaultCacheExpiration)
-
-	mockResponse := map[string]interface{}{
-		"success": true,
-		"message": fmt.Sprintf("API '%s' has been deleted successfully", params.Type),
-		"params":  params,
-	}
-	result, err := json.MarshalIndent(mockResponse, "", "  ")
-	if err != nil {
-		return "", fmt.Errorf("failed to format response: %w", err)
+	message := fmt.Sprintf("API '%s' has been deleted successfully", params.Type)
+	if err := cache.CommitModuleConfig(ctx, userReq.ConversationID, infoCache, cur, "deleteAPI", message); err != nil {
+		return "", err
 	}
 
-	return string(result), nil
+	return envelope.OK(message, params)
 }
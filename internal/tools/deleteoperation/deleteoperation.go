@@ -4,6 +4,8 @@ import (
 	"coder/api"
 	"coder/internal/cache"
 	"coder/internal/config"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -27,6 +29,11 @@ func (t *DeleteOperationTool) Info(ctx context.Context) (*schema.ToolInfo, error
 		Name: "deleteOperation",
 		Desc: "Delete an operation from a module",
 		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to edit, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
 			"title": {
 				Desc:     "The title of the operation to delete",
 				Type:     schema.String,
@@ -45,16 +52,17 @@ func (t *DeleteOperationTool) IsInvokable() bool {
 func (t *DeleteOperationTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
 	// Parse the arguments
 	var params struct {
-		Title string `json:"title"`
+		ModuleCode string `json:"module_code"`
+		Title      string `json:"title"`
 	}
 
 	if err := json.Unmarshal([]byte(args), &params); err != nil {
-		return "", fmt.Errorf("failed to parse arguments: %w", err)
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
 	}
 
 	// Validate required parameters
 	if params.Title == "" {
-		return "", fmt.Errorf("title cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "title cannot be empty")
 	}
 
 	// In a real implementation, we would delete the operation from the module
@@ -64,34 +72,20 @@ func (t *DeleteOperationTool) InvokableRun(ctx context.Context, args string, _ .
 	}
 	log.Printf("Processing LocalTool calls in message: %v", userReq)
 
-	infoCache, mapCur, _, userReq, err := cache.DecodeModuleFromCtx(ctx)
+	infoCache, cur, _, userReq, err := cache.DecodeModuleFromCtx(ctx, params.ModuleCode)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode module from context: %w", err)
 	}
 
-	tableOperation := mapCur["tableOperation"].([]interface{})
-	for index, field := range tableOperation {
-		if field.(map[string]interface{})["title"] == params.Title {
-			tableOperation = append(tableOperation[:index], tableOperation[index+1:]...)
-			break
-		}
+	if index := moduleconfig.ActionIndex(cur.TableOperation, params.Title); index >= 0 {
+		cur.TableOperation = append(cur.TableOperation[:index], cur.TableOperation[index+1:]...)
 	}
-	mapCur["tableOperation"] = tableOperation
 
 	// Save to cache
-	jsonCur, _ := json.Marshal(mapCur)
-	moduleCache := cache.NewModuleCacheData(infoCache.ModuleName, infoCache.ModuleCode, infoCache.Support, string(jsonCur))
-	cache.ModuleCacheInstance.Set(cache.CacheKey(userReq.ConversationID), moduleCache, cache.DefaultCacheExpiration)
-
-	mockResponse := map[string]interface{}{
-		"success": true,
-		"message": fmt.Sprintf("Operation '%s' has been deleted successfully", params.Title),
-		"params":  params,
-	}
-	result, err := json.MarshalIndent(mockResponse, "", "  ")
-	if err != nil {
-		return "", fmt.Errorf("failed to format response: %w", err)
+	message := fmt.Sprintf("Operation '%s' has been deleted successfully", params.Title)
+	if err := cache.CommitModuleConfig(ctx, userReq.ConversationID, infoCache, cur, "deleteOperation", message); err != nil {
+		return "", err
 	}
 
-	return string(result), nil
+	return envelope.OK(message, params)
 }
@@ -0,0 +1,70 @@
+package diffmodule
+
+import (
+	"coder/api"
+	"coder/internal/config"
+	"coder/internal/envelope"
+	"coder/internal/moduleops"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// DiffModuleTool is a tool for comparing the module draft with the configuration saved on the server
+type DiffModuleTool struct{}
+
+// NewDiffModuleTool creates a new diff module tool
+func NewDiffModuleTool() (*DiffModuleTool, error) {
+	return &DiffModuleTool{}, nil
+}
+
+// Info returns information about the tool
+func (t *DiffModuleTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "diffModule",
+		Desc: "Show what saving the module would change compared with the configuration loaded from the server, per section (createFields, tableFields, searchFields, tableActions, tableOperation, APIs, ...)",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to compare, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *DiffModuleTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *DiffModuleTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	var params struct {
+		ModuleCode string `json:"module_code"`
+	}
+	if strings.TrimSpace(args) != "" {
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+		}
+	}
+
+	userReq, ok := ctx.Value(config.StateKey).(*api.ChatRequest)
+	if !ok {
+		return "", fmt.Errorf("state not found in context")
+	}
+
+	infoCache, diff, err := moduleops.DiffDraft(userReq.ConversationID, params.ModuleCode)
+	if err != nil {
+		return "", err
+	}
+
+	if !diff.Changed {
+		return envelope.OK(fmt.Sprintf("Module '%s' (%s) has no unsaved changes", infoCache.ModuleName, infoCache.ModuleCode), diff)
+	}
+	return envelope.OK(fmt.Sprintf("Module '%s' (%s) has %d unsaved changes", infoCache.ModuleName, infoCache.ModuleCode, len(diff.Summary)), diff)
+}
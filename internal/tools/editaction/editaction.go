@@ -2,6 +2,8 @@ package editaction
 
 import (
 	"coder/internal/cache"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -24,6 +26,11 @@ func (t *EditActionTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 		Name: "editAction",
 		Desc: "Edit an action in a module",
 		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to edit, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
 			"old_title": {
 				Desc:     "The current title of the action to edit",
 				Type:     schema.String,
@@ -57,81 +64,50 @@ func (t *EditActionTool) IsInvokable() bool {
 func (t *EditActionTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
 	// Parse the arguments
 	var params struct {
-		OldTitle string                 `json:"old_title"`
-		Title    string                 `json:"title"`
-		Type     string                 `json:"type"`
-		Options  map[string]interface{} `json:"options"`
+		ModuleCode string                 `json:"module_code"`
+		OldTitle   string                 `json:"old_title"`
+		Title      string                 `json:"title"`
+		Type       string                 `json:"type"`
+		Options    map[string]interface{} `json:"options"`
 	}
 
 	if err := json.Unmarshal([]byte(args), &params); err != nil {
-		return "", fmt.Errorf("failed to parse arguments: %w", err)
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
 	}
 
 	// Validate required parameters
 	if params.OldTitle == "" {
-		return "", fmt.Errorf("old_title cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "old_title cannot be empty")
 	}
 	if params.Title == "" {
-		return "", fmt.Errorf("title cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "title cannot be empty")
 	}
 	if params.Type == "" {
-		return "", fmt.Errorf("type cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "type cannot be empty")
 	}
 	if params.Options == nil {
-		return "", fmt.Errorf("options cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "options cannot be empty")
 	}
 
 	// In a real implementation, we would edit the action in the module
-	infoCache, mapCur, _, userReq, err := cache.DecodeModuleFromCtx(ctx)
+	infoCache, cur, _, userReq, err := cache.DecodeModuleFromCtx(ctx, params.ModuleCode)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode module from context: %w", err)
 	}
 
-	tableAction, ok := mapCur["tableActions"].([]interface{})
-	if !ok {
-		// Initialize tableAction if it doesn't exist
-		tableAction = make([]interface{}, 0)
-	}
-
-	foundAndUpdated := false
-	for index, action := range tableAction {
-		actionMap := action.(map[string]interface{})
-		if title, ok := actionMap["title"].(string); ok && title == params.OldTitle {
-			tableAction[index] = map[string]interface{}{
-				"title":   params.Title,
-				"type":    params.Type,
-				"options": params.Options,
-			}
-			foundAndUpdated = true
-			break
-		}
-	}
-
-	if !foundAndUpdated {
-		// If we didn't find and update an existing action, add a new one
-		tableAction = append(tableAction, map[string]interface{}{
-			"title":   params.Title,
-			"type":    params.Type,
-			"options": params.Options,
-		})
+	// If we don't find an existing action, add a new one
+	action := moduleconfig.Action{Title: params.Title, Type: params.Type, Options: params.Options}
+	if index := moduleconfig.ActionIndex(cur.TableActions, params.OldTitle); index >= 0 {
+		cur.TableActions[index] = action
+	} else {
+		cur.TableActions = append(cur.TableActions, action)
 	}
 
-	mapCur["tableActions"] = tableAction
-
 	// Save to cache
-	jsonCur, _ := json.Marshal(mapCur)
-	moduleCache := cache.NewModuleCacheData(infoCache.ModuleName, infoCache.ModuleCode, infoCache.Support, string(jsonCur))
-	cache.ModuleCacheInstance.Set(cache.CacheKey(userReq.ConversationID), moduleCache, cache.DefaultCacheExpiration)
-
-	mockResponse := map[string]interface{}{
-		"success": true,
-		"message": fmt.Sprintf("Action '%s' has been edited successfully", params.Title),
-		"params":  params,
-	}
-	result, err := json.MarshalIndent(mockResponse, "", "  ")
-	if err != nil {
-		return "", fmt.Errorf("failed to format response: %w", err)
+	message := fmt.Sprintf("Action '%s' has been edited successfully", params.Title)
+	if err := cache.CommitModuleConfig(ctx, userReq.ConversationID, infoCache, cur, "editAction", message); err != nil {
+		return "", err
 	}
 
-	return string(result), nil
+	return envelope.OK(message, params)
 }
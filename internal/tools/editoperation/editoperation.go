@@ -2,6 +2,8 @@ package editoperation
 
 import (
 	"coder/internal/cache"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -24,6 +26,11 @@ func (t *EditOperationTool) Info(ctx context.Context) (*schema.ToolInfo, error)
 		Name: "editOperation",
 		Desc: "Edit an operation in a module",
 		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to edit, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
 			"old_title": {
 				Desc:     "The current title of the operation to edit",
 				Type:     schema.String,
@@ -57,69 +64,48 @@ func (t *EditOperationTool) IsInvokable() bool {
 func (t *EditOperationTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
 	// Parse the arguments
 	var params struct {
-		OldTitle string                 `json:"old_title"`
-		Title    string                 `json:"title"`
-		Type     string                 `json:"type"`
-		Options  map[string]interface{} `json:"options"`
+		ModuleCode string                 `json:"module_code"`
+		OldTitle   string                 `json:"old_title"`
+		Title      string                 `json:"title"`
+		Type       string                 `json:"type"`
+		Options    map[string]interface{} `json:"options"`
 	}
 
 	if err := json.Unmarshal([]byte(args), &params); err != nil {
-		return "", fmt.Errorf("failed to parse arguments: %w", err)
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
 	}
 
 	// Validate required parameters
 	if params.OldTitle == "" {
-		return "", fmt.Errorf("old_title cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "old_title cannot be empty")
 	}
 	if params.Title == "" {
-		return "", fmt.Errorf("title cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "title cannot be empty")
 	}
 	if params.Type == "" {
-		return "", fmt.Errorf("type cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "type cannot be empty")
 	}
 	if params.Options == nil {
-		return "", fmt.Errorf("options cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "options cannot be empty")
 	}
 
-	infoCache, mapCur, _, userReq, err := cache.DecodeModuleFromCtx(ctx)
+	infoCache, cur, _, userReq, err := cache.DecodeModuleFromCtx(ctx, params.ModuleCode)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode module from context: %w", err)
 	}
 
-	tableOperation := mapCur["tableOperation"].([]interface{})
-	title2Index := make(map[string]int)
-	for index, field := range tableOperation {
-		title2Index[field.(map[string]interface{})["title"].(string)] = index
-	}
-	if index, ok := title2Index[params.OldTitle]; ok {
-		tableOperation[index] = map[string]interface{}{
-			"title":   params.Title,
-			"type":    params.Type,
-			"options": params.Options,
-		}
+	operation := moduleconfig.Action{Title: params.Title, Type: params.Type, Options: params.Options}
+	if index := moduleconfig.ActionIndex(cur.TableOperation, params.OldTitle); index >= 0 {
+		cur.TableOperation[index] = operation
 	} else {
-		tableOperation = append(tableOperation, map[string]interface{}{
-			"title":   params.Title,
-			"type":    params.Type,
-			"options": params.Options,
-		})
+		cur.TableOperation = append(cur.TableOperation, operation)
 	}
-	mapCur["tableOperation"] = tableOperation
 
 	// Save to cache
-	jsonCur, _ := json.Marshal(mapCur)
-	moduleCache := cache.NewModuleCacheData(infoCache.ModuleName, infoCache.ModuleCode, infoCache.Support, string(jsonCur))
-	cache.ModuleCacheInstance.Set(cache.CacheKey(userReq.ConversationID), moduleCache, cache.DefaultCacheExpiration)
-
-	mockResponse := map[string]interface{}{
-		"success": true,
-		"message": fmt.Sprintf("Operation '%s' has been edited successfully", params.Title),
-		"params":  params,
-	}
-	result, err := json.MarshalIndent(mockResponse, "", "  ")
-	if err != nil {
-		return "", fmt.Errorf("failed to format response: %w", err)
+	message := fmt.Sprintf("Operation '%s' has been edited successfully", params.Title)
+	if err := cache.CommitModuleConfig(ctx, userReq.ConversationID, infoCache, cur, "editOperation", message); err != nil {
+		return "", err
 	}
 
-	return string(result), nil
+	return envelope.OK(message, params)
 }
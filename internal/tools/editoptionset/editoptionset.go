@@ -0,0 +1,99 @@
+package editoptionset
+
+import (
+	"coder/api"
+	"coder/internal/cache"
+	"coder/internal/config"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// EditOptionSetTool is a tool for changing an option set and the fields bound to it
+type EditOptionSetTool struct{}
+
+// NewEditOptionSetTool creates a new edit option set tool
+func NewEditOptionSetTool() (*EditOptionSetTool, error) {
+	return &EditOptionSetTool{}, nil
+}
+
+// Info returns information about the tool
+func (t *EditOptionSetTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "editOptionSet",
+		Desc: "Change the label or replace the options of an option set; the fields bound to it in every open module are updated as well",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"name": {
+				Desc:     "The name of the option set",
+				Type:     schema.String,
+				Required: true,
+			},
+			"label": {
+				Desc:     "The new Chinese name of the option set, unchanged when empty",
+				Type:     schema.String,
+				Required: false,
+			},
+			"options": {
+				Desc:     "The complete new list of options, each with label and value; unchanged when empty",
+				Type:     schema.Array,
+				ElemInfo: &schema.ParameterInfo{Type: schema.Object},
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *EditOptionSetTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *EditOptionSetTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	// Parse the arguments
+	var params moduleconfig.OptionSet
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+	}
+	if params.Label == "" && len(params.Options) == 0 {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "label or options must be given")
+	}
+
+	userReq, ok := ctx.Value(config.StateKey).(*api.ChatRequest)
+	if !ok {
+		return "", fmt.Errorf("state not found in context")
+	}
+
+	set, err := cache.GetOptionSet(userReq.ConversationID, params.Name)
+	if err != nil {
+		return "", err
+	}
+	if params.Label != "" {
+		set.Label = params.Label
+	}
+	if len(params.Options) > 0 {
+		set.Options = params.Options
+	}
+	if err := moduleconfig.CheckOptionSet(set); err != nil {
+		return "", err
+	}
+	if err := cache.SaveOptionSet(ctx, userReq.ConversationID, set); err != nil {
+		return "", err
+	}
+
+	// 同步刷新所有已打开模块中绑定该选项集的字段
+	updated, err := cache.RebindOptionSet(ctx, userReq.ConversationID, set, "editOptionSet")
+	if err != nil {
+		return "", err
+	}
+
+	return envelope.OK(fmt.Sprintf("Option set '%s' has been updated, fields refreshed in %d modules", set.Name, len(updated)), map[string]interface{}{
+		"optionSet": set,
+		"updated":   updated,
+	})
+}
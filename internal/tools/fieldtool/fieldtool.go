@@ -0,0 +1,216 @@
+package fieldtool
+
+import (
+	"coder/internal/cache"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// FieldTool adds, edits or deletes the fields of a range of a module; addField, editField and deleteField are
+// instances of it, and so are addSearch, editSearch and deleteSearch for the search range
+type FieldTool struct {
+	op string
+	// 固定编辑的范围及工具名称，为空时由参数 range_code 指定
+	rangeCode string
+	name      string
+}
+
+// New creates a field tool for op, one of moduleconfig.OpAdd, OpEdit and OpDelete
+func New(op string) FieldTool {
+	return FieldTool{op: op}
+}
+
+// NewForRange creates a field tool for op named name that always edits the range with the given code
+func NewForRange(op, rangeCode, name string) FieldTool {
+	return FieldTool{op: op, rangeCode: rangeCode, name: name}
+}
+
+// 各操作的工具名称、描述和结果用语
+var ops = map[string]struct {
+	name   string
+	desc   string
+	fields string
+	done   string
+}{
+	moduleconfig.OpAdd:    {"addField", "Add fields to %s; fields the range does not support or already has are reported and skipped", "The fields to add", "added"},
+	moduleconfig.OpEdit:   {"editField", "Replace fields of %s; fields the range does not have are reported and skipped", "The fields to replace, identified by their field key", "updated"},
+	moduleconfig.OpDelete: {"deleteField", "Delete fields from %s; fields the range does not have are reported and skipped", "The field keys to delete", "deleted"},
+}
+
+// Info returns information about the tool
+func (t *FieldTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	op := ops[t.op]
+	params := map[string]*schema.ParameterInfo{
+		"module_code": {
+			Desc:     "The code of the open module to edit, defaults to the active module",
+			Type:     schema.String,
+			Required: false,
+		},
+		"fields": {
+			Desc:     op.fields,
+			Type:     schema.Array,
+			Required: true,
+		},
+	}
+	if t.rangeCode != "" {
+		if t.op != moduleconfig.OpDelete {
+			// 兼容旧版单字段参数，按字段是否已存在新增或替换
+			params["fields"].Required = false
+			params["field"] = &schema.ParameterInfo{
+				Desc:     "Shorthand for a single field instead of fields: the field key (e.g., 'templateName'); the field is replaced when the range already has it, otherwise added",
+				Type:     schema.String,
+				Required: false,
+			}
+			params["label"] = &schema.ParameterInfo{
+				Desc:     "The display label of the single field (e.g., '模板名称')",
+				Type:     schema.String,
+				Required: false,
+			}
+			params["type"] = &schema.ParameterInfo{
+				Desc:     "The component type of the single field",
+				Type:     schema.String,
+				Required: false,
+			}
+			params["props"] = &schema.ParameterInfo{
+				Desc:     "The properties of the single field (e.g., placeholder text)",
+				Type:     schema.Object,
+				Required: false,
+			}
+		}
+		return &schema.ToolInfo{
+			Name:        t.name,
+			Desc:        fmt.Sprintf(op.desc, fmt.Sprintf("the %s range of a module", t.rangeCode)),
+			ParamsOneOf: schema.NewParamsOneOfByParams(params),
+		}, nil
+	}
+
+	params["range_name"] = &schema.ParameterInfo{
+		Desc:     "The name of the range as the user said it, used when range_code is not given",
+		Type:     schema.String,
+		Required: false,
+	}
+	params["range_code"] = &schema.ParameterInfo{
+		Desc:     "The code of the range: " + moduleconfig.RangeCodes(),
+		Type:     schema.String,
+		Required: true,
+	}
+	return &schema.ToolInfo{
+		Name:        op.name,
+		Desc:        fmt.Sprintf(op.desc, "a range of a module"),
+		ParamsOneOf: schema.NewParamsOneOfByParams(params),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *FieldTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *FieldTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	// Parse the arguments
+	var params struct {
+		ModuleCode string          `json:"module_code"`
+		RangeName  string          `json:"range_name"`
+		RangeCode  string          `json:"range_code"`
+		Fields     json.RawMessage `json:"fields"`
+		// 固定范围的工具还接受旧版的单字段参数
+		Field string                 `json:"field"`
+		Label string                 `json:"label"`
+		Type  string                 `json:"type"`
+		Props map[string]interface{} `json:"props"`
+	}
+
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+	}
+
+	rangeName := params.RangeCode
+	if t.rangeCode != "" {
+		rangeName = t.rangeCode
+	}
+	if rangeName == "" {
+		rangeName = params.RangeName
+	}
+	if rangeName == "" {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "range_code cannot be empty")
+	}
+	r, err := moduleconfig.ResolveRange(rangeName)
+	if err != nil {
+		return "", err
+	}
+
+	// 删除时 fields 为字段名列表，其余为字段配置列表
+	var fields []moduleconfig.Field
+	var names []string
+	upsert := false
+	switch {
+	case len(params.Fields) == 0 && params.Field != "" && t.rangeCode != "" && t.op != moduleconfig.OpDelete:
+		fields = []moduleconfig.Field{{Field: params.Field, Label: params.Label, Type: params.Type, Props: params.Props}}
+		upsert = true
+	case t.op == moduleconfig.OpDelete:
+		err = json.Unmarshal(params.Fields, &names)
+	default:
+		err = json.Unmarshal(params.Fields, &fields)
+	}
+	if err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse fields: %w", err)
+	}
+	if len(fields) == 0 && len(names) == 0 {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "fields cannot be empty")
+	}
+
+	infoCache, cur, support, userReq, err := cache.DecodeModuleFromCtx(ctx, params.ModuleCode)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode module from context: %w", err)
+	}
+
+	// 单字段参数沿用旧版行为：已存在则替换，否则新增
+	fieldOp := t.op
+	if upsert {
+		fieldOp = moduleconfig.OpAdd
+		if moduleconfig.FieldIndex(*cur.Fields(r.Section), params.Field) >= 0 {
+			fieldOp = moduleconfig.OpEdit
+		}
+	}
+
+	var report *moduleconfig.FieldReport
+	switch fieldOp {
+	case moduleconfig.OpAdd:
+		report = moduleconfig.AddFields(cur, support, r, fields)
+	case moduleconfig.OpEdit:
+		report = moduleconfig.EditFields(cur, r, fields)
+	case moduleconfig.OpDelete:
+		report = moduleconfig.DeleteFields(cur, r, names)
+	}
+
+	// 没有任何字段生效时返回错误，让模型根据建议修正后重试
+	op := ops[fieldOp]
+	if t.name != "" {
+		op.name = t.name
+	}
+	message := fmt.Sprintf("Fields for range '%s' (%s): %s", r.Name, r.Code, report.Summary())
+	if !report.Changed() {
+		code := envelope.CodeNotFound
+		if len(report.Unsupported) > 0 {
+			code = envelope.CodeUnsupported
+		} else if len(report.Duplicates) > 0 {
+			code = envelope.CodeInvalidArguments
+		}
+		return "", envelope.ErrorWithData(code, report, "no field was %s. %s", op.done, message)
+	}
+	if err := cache.CommitModuleConfig(ctx, userReq.ConversationID, infoCache, cur, op.name, message); err != nil {
+		return "", err
+	}
+
+	return envelope.OK(message, map[string]interface{}{
+		"range":  r,
+		"report": report,
+	})
+}
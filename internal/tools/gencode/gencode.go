@@ -12,6 +12,8 @@ import (
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/schema"
+
+	"coder/internal/envelope"
 )
 
 // ModuleField represents a field in a module
@@ -118,11 +120,11 @@ func (t *GenCodeTool) InvokableRun(ctx context.Context, args string, _ ...tool.O
 	}
 
 	if err := json.Unmarshal([]byte(args), &params); err != nil {
-		return "", fmt.Errorf("failed to parse arguments: %w", err)
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
 	}
 
 	if params.ModuleName == "" {
-		return "", fmt.Errorf("module_name cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "module_name cannot be empty")
 	}
 
 	// Create messages for the model
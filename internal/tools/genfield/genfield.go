@@ -4,6 +4,8 @@ import (
 	"coder/api"
 	"coder/internal/cache"
 	"coder/internal/config"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -32,7 +34,7 @@ func (t *GenFieldTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 				Required: true,
 			},
 			"attributes": {
-				Desc:     "An array of field definitions, each containing attributeName (must be in English), componentType (e.g. input, select), fieldName, fieldType (e.g. string, number), placeholder and required. When componentType is select, must include options field.",
+				Desc:     "An array of field definitions, each containing attributeName (must be in English), componentType (e.g. input, select), fieldName, fieldType (e.g. string, number), placeholder and required. When componentType is select, radio or checkbox, must include options ([{label, value}]) or optionSet, the name of an option set created with createOptionSet.",
 				Type:     schema.Array,
 				Required: true,
 			},
@@ -55,23 +57,46 @@ func (t *GenFieldTool) InvokableRun(ctx context.Context, args string, _ ...tool.
 			Note       string `json:"note"`
 		} `json:"entity"`
 		Attributes []struct {
-			AttributeName string              `json:"attributeName"`
-			ComponentType string              `json:"componentType"`
-			FieldName     string              `json:"fieldName"`
-			FieldType     string              `json:"fieldType"`
-			Placeholder   string              `json:"placeholder"`
-			Required      bool                `json:"required"`
-			Options       []map[string]string `json:"options,omitempty"`
+			AttributeName string                `json:"attributeName"`
+			ComponentType string                `json:"componentType"`
+			FieldName     string                `json:"fieldName"`
+			FieldType     string                `json:"fieldType"`
+			Placeholder   string                `json:"placeholder"`
+			Required      bool                  `json:"required"`
+			Options       []moduleconfig.Option `json:"options,omitempty"`
+			OptionSet     string                `json:"optionSet,omitempty"`
 		} `json:"attributes"`
 	}
 
 	if err := json.Unmarshal([]byte(args), &params); err != nil {
-		return "", fmt.Errorf("failed to parse arguments: %w", err)
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
 	}
-	fmt.Printf("Parsed arguments: entity=%+v, attributes count=%d\n", params.Entity, len(params.Attributes))
 
 	if len(params.Attributes) == 0 {
-		return "", fmt.Errorf("attributes cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "attributes cannot be empty")
+	}
+
+	userReq, hasState := ctx.Value(config.StateKey).(*api.ChatRequest)
+
+	// 选项字段使用模型给出的选项或选项集，选项集的选项在保存实体时再取最新值
+	for i := range params.Attributes {
+		attribute := &params.Attributes[i]
+		if !moduleconfig.IsOptionComponent(attribute.ComponentType) {
+			continue
+		}
+		if attribute.OptionSet != "" {
+			if !hasState {
+				return "", fmt.Errorf("state not found in context")
+			}
+			set, err := cache.GetOptionSet(userReq.ConversationID, attribute.OptionSet)
+			if err != nil {
+				return "", err
+			}
+			attribute.Options = set.Options
+		}
+		if err := moduleconfig.CheckOptions(attribute.Options); err != nil {
+			return "", envelope.Errorf(envelope.CodeInvalidArguments, "attribute '%s' is a %s and needs options or an optionSet: %w", attribute.AttributeName, attribute.ComponentType, err)
+		}
 	}
 
 	// Generate field configurations
@@ -92,34 +117,29 @@ func (t *GenFieldTool) InvokableRun(ctx context.Context, args string, _ ...tool.
 			"placeholder":   attribute.Placeholder,
 			"required":      attribute.Required,
 		}
-		if attribute.ComponentType == "select" {
-			attrMap["options"] = []map[string]string{
-				{"label": "Option 1", "value": "1"},
-				{"label": "Option 2", "value": "2"},
+		if moduleconfig.IsOptionComponent(attribute.ComponentType) {
+			attrMap["options"] = attribute.Options
+			if attribute.OptionSet != "" {
+				attrMap["optionSet"] = attribute.OptionSet
 			}
 		}
 		entityConfig["attributes"] = append(entityConfig["attributes"].([]map[string]interface{}), attrMap)
 	}
 
-	result, err := json.Marshal(entityConfig)
+	jsonCur, err := json.Marshal(entityConfig)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate field configurations: %w", err)
 	}
-	fmt.Printf("Generated field configurations: %s\n", string(result))
 
 	// Save to cache
-	userReq, ok := ctx.Value(config.StateKey).(*api.ChatRequest)
-	if ok {
-		cacheKey := cache.CacheKey(userReq.ConversationID)
-		fmt.Printf("Saving to cache with key: %s\n", cacheKey)
-		fmt.Printf("Cache content: %+v\n", entityConfig)
-
-		jsonCur, _ := json.Marshal(entityConfig)
+	if hasState {
 		entityName, _ := json.Marshal(params.Entity)
 		attributes, _ := json.Marshal(params.Attributes)
 		moduleCache := cache.NewEntityCacheData(string(entityName), string(attributes), string(jsonCur))
-		cache.EntityCacheInstance.Set(cacheKey, moduleCache, cache.DefaultCacheExpiration)
+		if err := cache.OpenEntity(ctx, userReq.ConversationID, params.Entity.EntityName, moduleCache); err != nil {
+			return "", err
+		}
 	}
 
-	return string(result), nil
+	return envelope.OK(fmt.Sprintf("Generated %d field configurations for entity '%s'", len(params.Attributes), params.Entity.EntityName), entityConfig)
 }
@@ -0,0 +1,72 @@
+package listchanges
+
+import (
+	"coder/api"
+	"coder/internal/cache"
+	"coder/internal/config"
+	"coder/internal/envelope"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ListChangesTool is a tool for listing the changes made to the module draft
+type ListChangesTool struct{}
+
+// NewListChangesTool creates a new list changes tool
+func NewListChangesTool() (*ListChangesTool, error) {
+	return &ListChangesTool{}, nil
+}
+
+// Info returns information about the tool
+func (t *ListChangesTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "listChanges",
+		Desc: "List the changes made to the module configuration since it was loaded, most recent first, including undone changes that can be redone",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module whose changes to list, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *ListChangesTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *ListChangesTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	var params struct {
+		ModuleCode string `json:"module_code"`
+	}
+	if strings.TrimSpace(args) != "" {
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+		}
+	}
+
+	userReq, ok := ctx.Value(config.StateKey).(*api.ChatRequest)
+	if !ok {
+		return "", fmt.Errorf("state not found in context")
+	}
+	key, err := cache.ResolveModuleKey(userReq.ConversationID, params.ModuleCode)
+	if err != nil {
+		return "", err
+	}
+
+	changes, err := cache.ListModuleChanges(key)
+	if err != nil {
+		return "", err
+	}
+
+	return envelope.OK(fmt.Sprintf("Module '%s' (%s) has %d changes that can be undone and %d that can be redone",
+		changes.ModuleName, changes.ModuleCode, len(changes.Undo), len(changes.Redo)), changes)
+}
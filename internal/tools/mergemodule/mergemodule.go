@@ -0,0 +1,134 @@
+package mergemodule
+
+import (
+	"coder/api"
+	"coder/internal/cache"
+	"coder/internal/config"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"coder/internal/moduleops"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// MergeModuleTool is a tool for merging the changes saved on the server into the module draft
+type MergeModuleTool struct{}
+
+// NewMergeModuleTool creates a new merge module tool
+func NewMergeModuleTool() (*MergeModuleTool, error) {
+	return &MergeModuleTool{}, nil
+}
+
+// Info returns information about the tool
+func (t *MergeModuleTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "mergeModule",
+		Desc: "Merge the module configuration saved on the server by someone else into the current draft (three-way merge), so that saveModule no longer reports a conflict",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to merge, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
+			"prefer": {
+				Desc:     "Which side wins for items changed differently in the draft and on the server: server or draft. Required when there are conflicts",
+				Type:     schema.String,
+				Enum:     []string{moduleops.PreferServer, moduleops.PreferDraft},
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *MergeModuleTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *MergeModuleTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	var params struct {
+		ModuleCode string `json:"module_code"`
+		Prefer     string `json:"prefer"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+	}
+	if params.Prefer != "" && params.Prefer != moduleops.PreferServer && params.Prefer != moduleops.PreferDraft {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "invalid prefer: %s. Must be one of: server, draft", params.Prefer)
+	}
+
+	userReq, ok := ctx.Value(config.StateKey).(*api.ChatRequest)
+	if !ok {
+		return "", fmt.Errorf("state not found in context")
+	}
+	cacheKey, infoCache, err := cache.GetModuleDraft(userReq.ConversationID, params.ModuleCode)
+	if err != nil {
+		return "", err
+	}
+
+	server, err := moduleops.FetchModuleConfig(ctx, infoCache.ModuleName, infoCache.ModuleCode)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch saved module config: %w", err)
+	}
+	version := moduleops.ConfigVersion(server)
+	if version == infoCache.LoadedVersion() {
+		return envelope.OK(fmt.Sprintf("Module '%s' (%s) has not been changed on the server, nothing to merge", infoCache.ModuleName, infoCache.ModuleCode), nil)
+	}
+
+	prefer := params.Prefer
+	if prefer == "" {
+		prefer = moduleops.PreferServer
+	}
+	merge, err := moduleops.MergeConfigs(infoCache.Original, infoCache.Cur, server.Cur, prefer)
+	if err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidConfig, "failed to merge module: %w", err)
+	}
+	// 存在冲突时需要用户决定保留哪一方
+	if len(merge.Conflicts) > 0 && params.Prefer == "" {
+		return "", envelope.ErrorWithData(envelope.CodeConflict, map[string]interface{}{"conflicts": merge.Conflicts},
+			"%d items were changed differently in the draft and on the server, ask the user whether to keep the server or the draft version and call mergeModule again with prefer", len(merge.Conflicts))
+	}
+
+	merged, err := json.Marshal(merge.Merged)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal merged module config: %w", err)
+	}
+	if err := validateMerged(infoCache, server, string(merged)); err != nil {
+		return "", err
+	}
+	message := fmt.Sprintf("Server changes of module '%s' (%s) have been merged into the draft with %d conflicts resolved in favor of the %s",
+		infoCache.ModuleName, infoCache.ModuleCode, len(merge.Conflicts), prefer)
+	if err := cache.RebaseModuleChange(ctx, cacheKey, infoCache, server.Cur, version, string(merged), "mergeModule", message); err != nil {
+		return "", err
+	}
+
+	return envelope.OK(message, map[string]interface{}{
+		"serverVersion": version,
+		"conflicts":     merge.Conflicts,
+	})
+}
+
+// validateMerged rejects a merged configuration with problems that neither the draft nor the server configuration has
+func validateMerged(draft *cache.ModuleCacheData, server *moduleops.ModuleConfigData, merged string) error {
+	mergedCfg, err := moduleconfig.Parse(merged)
+	if err != nil {
+		return err
+	}
+	supportJSON := server.Support
+	if supportJSON == "" {
+		supportJSON = draft.Support
+	}
+	support, err := moduleconfig.Parse(supportJSON)
+	if err != nil {
+		return fmt.Errorf("failed to parse support: %w", err)
+	}
+	// 草稿或服务端已有的问题不阻止合并
+	draftCfg, _ := moduleconfig.Parse(draft.Cur)
+	serverCfg, _ := moduleconfig.Parse(server.Cur)
+	return moduleconfig.ValidateMerge(draftCfg, serverCfg, mergedCfg, support)
+}
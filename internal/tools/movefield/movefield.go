@@ -0,0 +1,137 @@
+package movefield
+
+import (
+	"coder/internal/cache"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// MoveFieldTool is a tool for changing the order of the fields of a module
+type MoveFieldTool struct{}
+
+// NewMoveFieldTool creates a new move field tool
+func NewMoveFieldTool() (*MoveFieldTool, error) {
+	return &MoveFieldTool{}, nil
+}
+
+// Info returns information about the tool
+func (t *MoveFieldTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "moveField",
+		Desc: "Move a field of a module before or after another field or to a position, or arrange the fields in a given order (e.g., 按这个顺序排列: 名称, 状态, 创建时间)",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to edit, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
+			"range_name": {
+				Desc:     "The name of the range as the user said it, used when range_code is not given",
+				Type:     schema.String,
+				Required: false,
+			},
+			"range_code": {
+				Desc:     "The code of the range: " + moduleconfig.RangeCodes(),
+				Type:     schema.String,
+				Required: true,
+			},
+			"field": {
+				Desc:     "The field to move, used with one of before, after and index",
+				Type:     schema.String,
+				Required: false,
+			},
+			"before": {
+				Desc:     "Move the field right before this field",
+				Type:     schema.String,
+				Required: false,
+			},
+			"after": {
+				Desc:     "Move the field right after this field",
+				Type:     schema.String,
+				Required: false,
+			},
+			"index": {
+				Desc:     "Move the field to this 0-based position",
+				Type:     schema.Integer,
+				Required: false,
+			},
+			"order": {
+				Desc:     "The fields in their new order, instead of field; unlisted fields keep their order after them",
+				Type:     schema.Array,
+				ElemInfo: &schema.ParameterInfo{Type: schema.String},
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *MoveFieldTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *MoveFieldTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	// Parse the arguments
+	var params struct {
+		ModuleCode string   `json:"module_code"`
+		RangeName  string   `json:"range_name"`
+		RangeCode  string   `json:"range_code"`
+		Field      string   `json:"field"`
+		Order      []string `json:"order"`
+		moduleconfig.MoveTarget
+	}
+
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+	}
+
+	rangeName := params.RangeCode
+	if rangeName == "" {
+		rangeName = params.RangeName
+	}
+	r, err := moduleconfig.ResolveRange(rangeName)
+	if err != nil {
+		return "", err
+	}
+
+	if (params.Field == "") == (len(params.Order) == 0) {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "either field or order must be given")
+	}
+
+	infoCache, cur, _, userReq, err := cache.DecodeModuleFromCtx(ctx, params.ModuleCode)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode module from context: %w", err)
+	}
+
+	fields := cur.Fields(r.Section)
+
+	// 指定完整顺序时整体重排，否则移动单个字段
+	var moved []moduleconfig.Field
+	if len(params.Order) > 0 {
+		moved, err = moduleconfig.ReorderFields(*fields, params.Order)
+	} else {
+		moved, err = moduleconfig.MoveField(*fields, params.Field, params.MoveTarget)
+	}
+	if err != nil {
+		return "", err
+	}
+	*fields = moved
+
+	order := moduleconfig.FieldNames(moved)
+	message := fmt.Sprintf("Fields for range '%s' (%s) have been reordered: %v", r.Name, r.Code, order)
+	if err := cache.CommitModuleConfig(ctx, userReq.ConversationID, infoCache, cur, "moveField", message); err != nil {
+		return "", err
+	}
+
+	return envelope.OK(message, map[string]interface{}{
+		"range": r,
+		"order": order,
+	})
+}
@@ -0,0 +1,108 @@
+package patchmodule
+
+import (
+	"coder/internal/cache"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"coder/internal/moduleops"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// PatchModuleTool is a tool for changing any part of a module configuration with a JSON Patch or a merge patch
+type PatchModuleTool struct{}
+
+// NewPatchModuleTool creates a new patch module tool
+func NewPatchModuleTool() (*PatchModuleTool, error) {
+	return &PatchModuleTool{}, nil
+}
+
+// Info returns information about the tool
+func (t *PatchModuleTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "patchModule",
+		Desc: "Change any part of a module configuration that has no dedicated tool (layout, columns, pageName, field props or rules, ...). " +
+			"merge_patch is an RFC 7386 merge patch object (null removes a key) and is applied first; " +
+			"patch is a list of RFC 6902 JSON Patch operations ({op, path, value, from}, e.g. {\"op\":\"replace\",\"path\":\"/createFields/0/props/placeholder\",\"value\":\"请输入\"}). " +
+			"The result is checked against the supported configuration.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to edit, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
+			"merge_patch": {
+				Desc:     "RFC 7386 merge patch applied to the module configuration",
+				Type:     schema.Object,
+				Required: false,
+			},
+			"patch": {
+				Desc:     "RFC 6902 JSON Patch operations applied to the module configuration, in order",
+				Type:     schema.Array,
+				ElemInfo: &schema.ParameterInfo{Type: schema.Object},
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *PatchModuleTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *PatchModuleTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	// Parse the arguments
+	var params struct {
+		ModuleCode string                        `json:"module_code"`
+		MergePatch json.RawMessage               `json:"merge_patch"`
+		Patch      []moduleconfig.PatchOperation `json:"patch"`
+	}
+
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+	}
+
+	// merge_patch 为 null 时视为未提供
+	mergePatch := strings.TrimSpace(string(params.MergePatch))
+	if mergePatch == "null" {
+		mergePatch = ""
+	}
+	if mergePatch == "" && len(params.Patch) == 0 {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "merge_patch or patch must be given")
+	}
+
+	infoCache, cur, _, userReq, err := cache.DecodeModuleFromCtx(ctx, params.ModuleCode)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode module from context: %w", err)
+	}
+
+	patched, err := moduleconfig.Patch(cur, json.RawMessage(mergePatch), params.Patch)
+	if err != nil {
+		return "", err
+	}
+	after, err := patched.JSON()
+	if err != nil {
+		return "", err
+	}
+	diff, err := moduleops.Diff(infoCache.Cur, after)
+	if err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidConfig, "failed to diff module: %w", err)
+	}
+	if !diff.Changed {
+		return envelope.OK("The patch does not change the module", diff)
+	}
+
+	message := fmt.Sprintf("Module has been patched successfully: %s", strings.Join(diff.Summary, "; "))
+	if err := cache.CommitModuleConfig(ctx, userReq.ConversationID, infoCache, patched, "patchModule", message); err != nil {
+		return "", err
+	}
+
+	return envelope.OK(message, diff)
+}
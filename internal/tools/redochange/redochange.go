@@ -0,0 +1,77 @@
+package redochange
+
+import (
+	"coder/api"
+	"coder/internal/cache"
+	"coder/internal/config"
+	"coder/internal/envelope"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// RedoChangeTool is a tool for reapplying the last undone change of the module draft
+type RedoChangeTool struct{}
+
+// NewRedoChangeTool creates a new redo change tool
+func NewRedoChangeTool() (*RedoChangeTool, error) {
+	return &RedoChangeTool{}, nil
+}
+
+// Info returns information about the tool
+func (t *RedoChangeTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "redoChange",
+		Desc: "Reapply the last change undone with undoLastChange. Use it when the user asks to redo or restore a change they just undid (e.g. 恢复刚才撤销的修改)",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module whose undone change to redo, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *RedoChangeTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *RedoChangeTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	var params struct {
+		ModuleCode string `json:"module_code"`
+	}
+	if strings.TrimSpace(args) != "" {
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+		}
+	}
+
+	userReq, ok := ctx.Value(config.StateKey).(*api.ChatRequest)
+	if !ok {
+		return "", fmt.Errorf("state not found in context")
+	}
+	key, err := cache.ResolveModuleKey(userReq.ConversationID, params.ModuleCode)
+	if err != nil {
+		return "", err
+	}
+
+	draft, revision, err := cache.RedoModuleChange(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	return envelope.OK(fmt.Sprintf("Change #%d (%s) has been redone", revision.ID, revision.Summary), map[string]interface{}{
+		"redone":    revision.ID,
+		"tool":      revision.Tool,
+		"summary":   revision.Summary,
+		"undoCount": len(draft.Undo),
+		"redoCount": len(draft.Redo),
+	})
+}
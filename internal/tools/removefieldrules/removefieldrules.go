@@ -0,0 +1,109 @@
+package removefieldrules
+
+import (
+	"coder/internal/cache"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// RemoveFieldRulesTool is a tool for removing validation rules from a form field of a module
+type RemoveFieldRulesTool struct{}
+
+// NewRemoveFieldRulesTool creates a new remove field rules tool
+func NewRemoveFieldRulesTool() (*RemoveFieldRulesTool, error) {
+	return &RemoveFieldRulesTool{}, nil
+}
+
+// Info returns information about the tool
+func (t *RemoveFieldRulesTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "removeFieldRules",
+		Desc: "Remove validation rules from a field of the create and update forms of a module, by rule type or all of them",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to edit, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
+			"range_codes": {
+				Desc:     "The form ranges to change, create and/or update; defaults to every form that has the field",
+				Type:     schema.Array,
+				ElemInfo: &schema.ParameterInfo{Type: schema.String},
+				Required: false,
+			},
+			"field": {
+				Desc:     "The field key of the field",
+				Type:     schema.String,
+				Required: true,
+			},
+			"types": {
+				Desc:     "The rule types to remove (e.g., required, length, pattern); all rules when empty",
+				Type:     schema.Array,
+				ElemInfo: &schema.ParameterInfo{Type: schema.String},
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *RemoveFieldRulesTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *RemoveFieldRulesTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	// Parse the arguments
+	var params struct {
+		ModuleCode string   `json:"module_code"`
+		RangeCodes []string `json:"range_codes"`
+		Field      string   `json:"field"`
+		Types      []string `json:"types"`
+	}
+
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+	}
+
+	if params.Field == "" {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "field cannot be empty")
+	}
+
+	infoCache, cur, _, userReq, err := cache.DecodeModuleFromCtx(ctx, params.ModuleCode)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode module from context: %w", err)
+	}
+
+	targets, err := moduleconfig.RuleTargets(cur, params.RangeCodes, params.Field)
+	if err != nil {
+		return "", err
+	}
+	removed := make(map[string][]string)
+	sections := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if types := moduleconfig.RemoveRules(target.Field, params.Types); len(types) > 0 {
+			removed[target.Range.Section] = types
+			sections = append(sections, target.Range.Section)
+		}
+	}
+	if len(removed) == 0 {
+		return "", envelope.Errorf(envelope.CodeNotFound, "field '%s' has no rules of types %v", params.Field, params.Types)
+	}
+
+	message := fmt.Sprintf("Rules have been removed from field '%s' in %s", params.Field, strings.Join(sections, ", "))
+	if err := cache.CommitModuleConfig(ctx, userReq.ConversationID, infoCache, cur, "removeFieldRules", message); err != nil {
+		return "", err
+	}
+
+	return envelope.OK(message, map[string]interface{}{
+		"field":   params.Field,
+		"removed": removed,
+	})
+}
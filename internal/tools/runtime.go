@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+
+	"coder/app"
+	"coder/internal/envelope"
+)
+
+// defaultToolTimeout is the timeout of a tool call when tools.timeout is not configured
+const defaultToolTimeout = 60 * time.Second
+
+// Timeout returns the configured timeout of a tool; per-tool settings override the default
+func Timeout(toolName string) time.Duration {
+	if app.Config != nil {
+		if seconds := app.Config.Tools.Timeouts[toolName]; seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		if app.Config.Tools.Timeout > 0 {
+			return time.Duration(app.Config.Tools.Timeout) * time.Second
+		}
+	}
+	return defaultToolTimeout
+}
+
+// toolOutcome is the output of a tool call running in its own goroutine
+type toolOutcome struct {
+	output string
+	err    error
+}
+
+// ExecuteTool 执行指定的工具，返回统一格式的结果。
+// 工具在超时或上下文取消后返回 timeout/canceled 错误，panic 转换为 *envelope.PanicError
+func (tm *ToolManager) ExecuteTool(ctx context.Context, toolName string, arguments string) (string, error) {
+	// 获取工具
+	tm.mu.RLock()
+	selectTools, exists := tm.tools[toolName]
+	tm.mu.RUnlock()
+
+	if !exists {
+		return "", envelope.Errorf(envelope.CodeNotFound, "tool '%s' not found", toolName)
+	}
+
+	// 检查工具是否可调用
+	invokableTool, ok := selectTools.(tool.InvokableTool)
+	if !ok {
+		return "", envelope.Errorf(envelope.CodeUnsupported, "tool '%s' is not invokable", toolName)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout(toolName))
+	defer cancel()
+
+	// 执行工具
+	done := make(chan toolOutcome, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				stack := string(debug.Stack())
+				log.Printf("Recovered from panic in tool %s: %v\n%s", toolName, r, stack)
+				done <- toolOutcome{err: &envelope.PanicError{Tool: toolName, Value: r, Stack: stack}}
+			}
+		}()
+		output, err := invokableTool.InvokableRun(ctx, arguments)
+		done <- toolOutcome{output: output, err: err}
+	}()
+
+	select {
+	case outcome := <-done:
+		if outcome.err != nil {
+			return "", outcome.err
+		}
+		return envelope.Normalize(outcome.output), nil
+	case <-ctx.Done():
+		// 工具无法被强制终止，超时后其结果会被丢弃；ctx 已结束，cache 包的写入函数不会再修改会话状态
+		return "", fmt.Errorf("tool '%s' did not finish: %w", toolName, ctx.Err())
+	}
+}
@@ -6,12 +6,15 @@ import (
 	"coder/app"
 	"coder/internal/cache"
 	"coder/internal/config"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/schema"
@@ -30,6 +33,13 @@ func (t *SaveEntityTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 	return &schema.ToolInfo{
 		Name: "saveEntity",
 		Desc: "Save the configuration of an entity",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"entity_name": {
+				Desc:     "The entityName of the generated entity to save, defaults to the active entity",
+				Type:     schema.String,
+				Required: false,
+			},
+		}),
 	}, nil
 }
 
@@ -45,34 +55,84 @@ func (t *SaveEntityTool) IsInvokable() bool {
 	return true
 }
 
-// InvokableRun runs the tool
-func (t *SaveEntityTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+// RequiresConfirmation indicates that saving must be approved by the user
+func (t *SaveEntityTool) RequiresConfirmation() bool {
+	return true
+}
+
+// Preview returns the entity, its attributes and the generated page configuration that the save would create
+func (t *SaveEntityTool) Preview(ctx context.Context, args string) (interface{}, error) {
+	_, infoCache, _, payload, err := buildEntityPayload(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var attributes interface{}
+	if err := json.Unmarshal([]byte(infoCache.Attributes), &attributes); err != nil {
+		return nil, fmt.Errorf("failed to parse attributes: %w", err)
+	}
+
+	// 实体保存会新建实体、字段和页面配置
+	return map[string]interface{}{
+		"entityName":   infoCache.EntityName,
+		"attributes":   attributes,
+		"entityConfig": payload["entityConfig"],
+	}, nil
+}
+
+// buildEntityPayload builds the entity configuration payload from the entity draft named in args, or the active
+// entity draft of the current conversation, and returns it with the conversation ID
+func buildEntityPayload(ctx context.Context, args string) (string, *cache.EntityCacheData, map[string]interface{}, map[string]interface{}, error) {
+	var params struct {
+		EntityName string `json:"entity_name"`
+	}
+	if strings.TrimSpace(args) != "" {
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", nil, nil, nil, envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+		}
+	}
+
 	// Get state
 	userReq, ok := ctx.Value(config.StateKey).(*api.ChatRequest)
 	if !ok {
-		return "", fmt.Errorf("state not found in context")
+		return "", nil, nil, nil, fmt.Errorf("state not found in context")
 	}
 	log.Printf("Processing LocalTool calls in message: %v", userReq)
 
 	// Get cache
-	cacheKey := cache.CacheKey(userReq.ConversationID)
-	info, ok := cache.EntityCacheInstance.Get(cacheKey)
+	cacheKey, err := cache.ResolveEntityKey(userReq.ConversationID, params.EntityName)
+	if err != nil {
+		return "", nil, nil, nil, err
+	}
+	infoCache, ok := cache.EntityCacheInstance.Get(cacheKey)
 	if !ok {
-		return "", fmt.Errorf("failed to get cache")
+		return "", nil, nil, nil, envelope.Errorf(envelope.CodeNotFound, "failed to get cache, generate the entity with genField first")
 	}
-	infoCache := info.(*cache.EntityCacheData)
 
 	// Create the request payload
 	// Parse entity name info
 	var entityNameInfo map[string]interface{}
 	if err := json.Unmarshal([]byte(infoCache.EntityName), &entityNameInfo); err != nil {
-		return "", fmt.Errorf("failed to parse entity name info: %w", err)
+		return "", nil, nil, nil, fmt.Errorf("failed to parse entity name info: %w", err)
 	}
 
 	// Parse attributes
 	var attributes []map[string]interface{}
 	if err := json.Unmarshal([]byte(infoCache.Attributes), &attributes); err != nil {
-		return "", fmt.Errorf("failed to parse attributes: %w", err)
+		return "", nil, nil, nil, fmt.Errorf("failed to parse attributes: %w", err)
+	}
+
+	// 绑定选项集的字段使用选项集的最新选项，各范围的选项保持一致
+	for _, attr := range attributes {
+		name, _ := attr["optionSet"].(string)
+		if name == "" {
+			continue
+		}
+		set, err := cache.GetOptionSet(userReq.ConversationID, name)
+		if err != nil {
+			return "", nil, nil, nil, err
+		}
+		attr["options"] = set.Options
 	}
 
 	// Convert attributes to createFields format
@@ -91,6 +151,9 @@ func (t *SaveEntityTool) InvokableRun(ctx context.Context, args string, _ ...too
 		if attr["required"] == true {
 			field["rules"] = []map[string]interface{}{{"type": "required"}}
 		}
+		if attr["optionSet"] != nil {
+			field["optionSet"] = attr["optionSet"]
+		}
 		createFields = append(createFields, field)
 	}
 
@@ -119,24 +182,28 @@ func (t *SaveEntityTool) InvokableRun(ctx context.Context, args string, _ ...too
 			"label": attr["fieldName"],
 			"type":  "plain",
 		}
-		if attr["componentType"] == "select" || attr["componentType"] == "radio" {
+		if componentType, _ := attr["componentType"].(string); moduleconfig.IsOptionComponent(componentType) {
 			field["options"] = map[string]interface{}{
 				"map": attr["options"],
 				"chy": attr["options"],
 			}
+			if attr["optionSet"] != nil {
+				field["optionSet"] = attr["optionSet"]
+			}
 		}
 		viewFields = append(viewFields, field)
 	}
 
+	// 页面标题使用实体的中文名称，没有时使用实体名
+	pageTitle, _ := entityNameInfo["name"].(string)
+	if pageTitle == "" {
+		pageTitle = fmt.Sprint(entityNameInfo["entityName"])
+	}
+
 	payload := map[string]interface{}{
 		"entityName": infoCache.EntityName,
 		"entityConfig": map[string]interface{}{
-			"pageName": map[string]interface{}{
-				"table": "字段模板",
-				"new":   "新增字段模板",
-				"edit":  "更改字段模板",
-				"name":  "",
-			},
+			"pageName":     moduleconfig.DefaultPageName(pageTitle),
 			"createFields": createFields,
 			"updateFields": createFields,
 			"tableFields":  tableFields,
@@ -147,11 +214,8 @@ func (t *SaveEntityTool) InvokableRun(ctx context.Context, args string, _ ...too
 			"getAPI":       fmt.Sprintf("/api/adm/data/services/%s/[id]", entityNameInfo["entityName"]),
 			"updateAPI":    fmt.Sprintf("/api/adm/data/services/%s/[id]", entityNameInfo["entityName"]),
 			"deleteAPI":    fmt.Sprintf("/api/adm/data/services/%s/(id)", entityNameInfo["entityName"]),
-			"columns":      1,
-			"layout": map[string]string{
-				"table": "Content",
-				"form":  "TitleContent",
-			},
+			"columns":      moduleconfig.DefaultColumns,
+			"layout":       moduleconfig.DefaultLayout(),
 			"tableActions": []map[string]interface{}{
 				{
 					"title": "添加",
@@ -187,6 +251,16 @@ func (t *SaveEntityTool) InvokableRun(ctx context.Context, args string, _ ...too
 		},
 	}
 
+	return userReq.ConversationID, infoCache, entityNameInfo, payload, nil
+}
+
+// InvokableRun runs the tool
+func (t *SaveEntityTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	conversationID, infoCache, entityNameInfo, payload, err := buildEntityPayload(ctx, args)
+	if err != nil {
+		return "", err
+	}
+
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request payload: %w", err)
@@ -216,7 +290,7 @@ func (t *SaveEntityTool) InvokableRun(ctx context.Context, args string, _ ...too
 	req1.Header.Set("Content-Type", "application/json")
 	resp1, err := app.ConfigClient.Client.Do(req1)
 	if err != nil {
-		return "", fmt.Errorf("failed to send entity request: %w", err)
+		return "", envelope.Errorf(envelope.CodeUpstream, "failed to send entity request: %w", err)
 	}
 	defer resp1.Body.Close()
 	body1, err := io.ReadAll(resp1.Body)
@@ -234,7 +308,7 @@ func (t *SaveEntityTool) InvokableRun(ctx context.Context, args string, _ ...too
 	req2.Header.Set("Content-Type", "application/json")
 	resp2, err := app.ConfigClient.Client.Do(req2)
 	if err != nil {
-		return "", fmt.Errorf("failed to send field request: %w", err)
+		return "", envelope.Errorf(envelope.CodeUpstream, "failed to send field request: %w", err)
 	}
 	defer resp2.Body.Close()
 	body2, err := io.ReadAll(resp2.Body)
@@ -262,7 +336,7 @@ func (t *SaveEntityTool) InvokableRun(ctx context.Context, args string, _ ...too
 	req3.Header.Set("Content-Type", "application/json")
 	resp3, err := app.ConfigClient.Client.Do(req3)
 	if err != nil {
-		return "", fmt.Errorf("failed to send dynamicForm request: %w", err)
+		return "", envelope.Errorf(envelope.CodeUpstream, "failed to send dynamicForm request: %w", err)
 	}
 	defer resp3.Body.Close()
 	body3, err := io.ReadAll(resp3.Body)
@@ -281,9 +355,12 @@ func (t *SaveEntityTool) InvokableRun(ctx context.Context, args string, _ ...too
 		fmt.Sprint(dynamicFormPayload["moduleCode"]),
 		string(configJsonStr),
 		string(configJsonStr),
+		string(configJsonStr),
 	)
-	cache.ModuleCacheInstance.Set(cacheKey, moduleCache, cache.DefaultCacheExpiration)
+	moduleCache.Version = moduleconfig.HashConfig(string(configJsonStr))
+	if err := cache.OpenModule(ctx, conversationID, moduleCache); err != nil {
+		return "", err
+	}
 
-	fmt.Println("Cached module", dynamicFormPayload["moduleName"], dynamicFormPayload["moduleCode"], "with key", cacheKey)
 	return string(body1), nil
 }
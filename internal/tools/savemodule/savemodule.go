@@ -6,12 +6,17 @@ import (
 	"coder/app"
 	"coder/internal/cache"
 	"coder/internal/config"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"coder/internal/moduleops"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/schema"
@@ -31,6 +36,13 @@ func (t *SaveModuleTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 	return &schema.ToolInfo{
 		Name: "saveModule",
 		Desc: "Save the configuration of a module",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to save, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
+		}),
 	}, nil
 }
 
@@ -46,23 +58,147 @@ func (t *SaveModuleTool) IsInvokable() bool {
 	return true
 }
 
-// InvokableRun runs the tool
-func (t *SaveModuleTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+// RequiresConfirmation indicates that saving must be approved by the user
+func (t *SaveModuleTool) RequiresConfirmation() bool {
+	return true
+}
+
+// Preview returns the sections of the module configuration that the save would change
+func (t *SaveModuleTool) Preview(ctx context.Context, args string) (interface{}, error) {
+	_, infoCache, err := getModuleCache(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := moduleops.Diff(infoCache.Original, infoCache.Cur)
+	if err != nil {
+		return nil, envelope.Errorf(envelope.CodeInvalidConfig, "failed to diff module: %w", err)
+	}
+
+	preview := map[string]interface{}{
+		"moduleName": infoCache.ModuleName,
+		"moduleCode": infoCache.ModuleCode,
+		"changes":    diff,
+	}
+
+	// 提前提示保存时会被拒绝的配置问题和冲突
+	var invalidErr *envelope.Error
+	if err := validateDraft(infoCache); errors.As(err, &invalidErr) {
+		preview["invalid"] = invalidErr.Error()
+		preview["issues"] = invalidErr.Data
+	}
+
+	var conflictErr *envelope.Error
+	if err := checkConflict(ctx, infoCache); errors.As(err, &conflictErr) && conflictErr.Code == envelope.CodeConflict {
+		preview["conflict"] = conflictErr.Data
+	} else if err != nil {
+		log.Printf("Failed to check module conflict: %v", err)
+	}
+	return preview, nil
+}
+
+// validateDraft checks the draft configuration against the module support configuration; problems already present
+// in the configuration saved on the server are tolerated
+func validateDraft(infoCache *cache.ModuleCacheData) error {
+	cur, err := moduleconfig.Parse(infoCache.Cur)
+	if err != nil {
+		return err
+	}
+	support, err := moduleconfig.Parse(infoCache.Support)
+	if err != nil {
+		return fmt.Errorf("failed to parse support: %w", err)
+	}
+	original, _ := moduleconfig.Parse(infoCache.Original)
+	return moduleconfig.ValidateChange(original, cur, support)
+}
+
+// checkConflict re-fetches the saved module and, when it was saved by someone else after the draft was loaded,
+// returns a conflict error carrying a three-way merge proposal
+func checkConflict(ctx context.Context, infoCache *cache.ModuleCacheData) error {
+	server, err := moduleops.FetchModuleConfig(ctx, infoCache.ModuleName, infoCache.ModuleCode)
+	if err != nil {
+		return fmt.Errorf("failed to fetch saved module config: %w", err)
+	}
+
+	expected := infoCache.LoadedVersion()
+	version := moduleops.ConfigVersion(server)
+	if version == expected {
+		return nil
+	}
+
+	merge, err := moduleops.MergeConfigs(infoCache.Original, infoCache.Cur, server.Cur, moduleops.PreferServer)
+	if err != nil {
+		return envelope.Errorf(envelope.CodeInvalidConfig, "failed to merge module: %w", err)
+	}
+	serverChanges, err := moduleops.Diff(infoCache.Original, server.Cur)
+	if err != nil {
+		return envelope.Errorf(envelope.CodeInvalidConfig, "failed to diff module: %w", err)
+	}
+	draftChanges, err := moduleops.Diff(infoCache.Original, infoCache.Cur)
+	if err != nil {
+		return envelope.Errorf(envelope.CodeInvalidConfig, "failed to diff module: %w", err)
+	}
+
+	return envelope.ErrorWithData(envelope.CodeConflict, map[string]interface{}{
+		"loadedVersion": expected,
+		"serverVersion": version,
+		"serverChanges": serverChanges.Summary,
+		"draftChanges":  draftChanges.Summary,
+		"conflicts":     merge.Conflicts,
+		"merged":        merge.Merged,
+	}, "module '%s' (%s) was saved by someone else after it was loaded (%d conflicting items), merge the server changes with mergeModule before saving again",
+		infoCache.ModuleName, infoCache.ModuleCode, len(merge.Conflicts))
+}
+
+// getModuleCache returns the module draft named in args, or the active module draft, of the current conversation
+func getModuleCache(ctx context.Context, args string) (string, *cache.ModuleCacheData, error) {
+	var params struct {
+		ModuleCode string `json:"module_code"`
+	}
+	if strings.TrimSpace(args) != "" {
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", nil, envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+		}
+	}
+
 	// 获取state
 	userReq, ok := ctx.Value(config.StateKey).(*api.ChatRequest)
 	if !ok {
-		return "", fmt.Errorf("state not found in context")
+		return "", nil, fmt.Errorf("state not found in context")
 	}
 	log.Printf("Processing LocalTool calls in message: %v", userReq)
 
 	// 获取cache
-	cacheKey := cache.CacheKey(userReq.ConversationID)
-	info, ok := cache.ModuleCacheInstance.Get(cacheKey)
-	if !ok {
-		return "", fmt.Errorf("failed to get cache")
+	cacheKey, infoCache, err := cache.GetModuleDraft(userReq.ConversationID, params.ModuleCode)
+	if err != nil {
+		return "", nil, err
 	}
-	infoCache := info.(*cache.ModuleCacheData)
 	log.Printf("Cache key: %v, Module info: %+v", cacheKey, infoCache)
+	return cacheKey, infoCache, nil
+}
+
+// InvokableRun runs the tool
+func (t *SaveModuleTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	cacheKey, infoCache, err := getModuleCache(ctx, args)
+	if err != nil {
+		return "", err
+	}
+
+	// 保存前记录与服务端配置的差异，随保存结果一起返回
+	diff, err := moduleops.Diff(infoCache.Original, infoCache.Cur)
+	if err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidConfig, "failed to diff module: %w", err)
+	}
+
+	// 配置不合法时不提交到服务端
+	if err := validateDraft(infoCache); err != nil {
+		return "", err
+	}
+
+	// 保存前重新获取服务端配置，被他人修改过时拒绝覆盖
+	if err := checkConflict(ctx, infoCache); err != nil {
+		return "", err
+	}
 
 	// Create the request payload
 	payload := map[string]interface{}{
@@ -91,7 +227,7 @@ func (t *SaveModuleTool) InvokableRun(ctx context.Context, args string, _ ...too
 	// Send the request
 	resp, err := app.ConfigClient.Client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return "", envelope.Errorf(envelope.CodeUpstream, "failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -109,8 +245,28 @@ func (t *SaveModuleTool) InvokableRun(ctx context.Context, args string, _ ...too
 
 	// Check the response code
 	if apiResp.Code != 200 {
-		return "", fmt.Errorf("API error: %s", apiResp.Msg)
+		return "", envelope.Errorf(envelope.CodeUpstream, "API error: %s", apiResp.Msg)
+	}
+
+	// 记录保存后的服务端版本，作为下次保存的比较基准。版本取自本次保存的响应或提交内容的哈希，
+	// 不重新获取，以免把他人随后保存的版本当作自己的
+	if err := cache.MarkModuleSaved(ctx, cacheKey, infoCache.Cur, savedVersion(apiResp.Data, infoCache.Cur)); err != nil {
+		return "", err
 	}
 
-	return string(body), nil
+	return envelope.OK(fmt.Sprintf("Module '%s' (%s) has been saved successfully with %d changes", infoCache.ModuleName, infoCache.ModuleCode, len(diff.Summary)), map[string]interface{}{
+		"result": apiResp.Data,
+		"diff":   diff,
+	})
+}
+
+// savedVersion returns the version the config service reports for a save, or the hash of the saved config when it
+// reports none
+func savedVersion(data interface{}, saved string) string {
+	if m, ok := data.(map[string]interface{}); ok {
+		if version, ok := m["version"].(string); ok && version != "" {
+			return version
+		}
+	}
+	return moduleconfig.HashConfig(saved)
 }
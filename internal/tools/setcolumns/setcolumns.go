@@ -0,0 +1,77 @@
+package setcolumns
+
+import (
+	"coder/internal/cache"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// SetColumnsTool is a tool for changing the number of columns of the form grid of a module
+type SetColumnsTool struct{}
+
+// NewSetColumnsTool creates a new set columns tool
+func NewSetColumnsTool() (*SetColumnsTool, error) {
+	return &SetColumnsTool{}, nil
+}
+
+// Info returns information about the tool
+func (t *SetColumnsTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "setColumns",
+		Desc: fmt.Sprintf("Change how many fields the create and update forms of a module show per row, from 1 to %d", moduleconfig.MaxColumns),
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to edit, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
+			"columns": {
+				Desc:     "The number of columns",
+				Type:     schema.Integer,
+				Required: true,
+			},
+		}),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *SetColumnsTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *SetColumnsTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	// Parse the arguments
+	var params struct {
+		ModuleCode string `json:"module_code"`
+		Columns    int    `json:"columns"`
+	}
+
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+	}
+
+	infoCache, cur, _, userReq, err := cache.DecodeModuleFromCtx(ctx, params.ModuleCode)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode module from context: %w", err)
+	}
+
+	if err := moduleconfig.SetColumns(cur, params.Columns); err != nil {
+		return "", err
+	}
+
+	message := fmt.Sprintf("Forms now show %d columns", params.Columns)
+	if err := cache.CommitModuleConfig(ctx, userReq.ConversationID, infoCache, cur, "setColumns", message); err != nil {
+		return "", err
+	}
+
+	return envelope.OK(message, map[string]interface{}{
+		"columns": params.Columns,
+	})
+}
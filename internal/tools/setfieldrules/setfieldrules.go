@@ -0,0 +1,114 @@
+package setfieldrules
+
+import (
+	"coder/internal/cache"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// SetFieldRulesTool is a tool for adding validation rules to a form field of a module
+type SetFieldRulesTool struct{}
+
+// NewSetFieldRulesTool creates a new set field rules tool
+func NewSetFieldRulesTool() (*SetFieldRulesTool, error) {
+	return &SetFieldRulesTool{}, nil
+}
+
+// Info returns information about the tool
+func (t *SetFieldRulesTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "setFieldRules",
+		Desc: "Add validation rules to a field of the create and update forms of a module (e.g., 手机号必须是11位数字 → [{\"type\":\"phone\"}] or [{\"type\":\"pattern\",\"pattern\":\"^\\\\d{11}$\",\"message\":\"请输入11位数字\"}]). " +
+			"A rule replaces the field's rule of the same type. Rule types: " + moduleconfig.RuleVocabulary() + ". Every rule may have a custom message.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to edit, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
+			"range_codes": {
+				Desc:     "The form ranges to change, create and/or update; defaults to every form that has the field",
+				Type:     schema.Array,
+				ElemInfo: &schema.ParameterInfo{Type: schema.String},
+				Required: false,
+			},
+			"field": {
+				Desc:     "The field key of the field",
+				Type:     schema.String,
+				Required: true,
+			},
+			"rules": {
+				Desc:     "The rules to set, each with type and its parameters",
+				Type:     schema.Array,
+				ElemInfo: &schema.ParameterInfo{Type: schema.Object},
+				Required: true,
+			},
+		}),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *SetFieldRulesTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *SetFieldRulesTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	// Parse the arguments
+	var params struct {
+		ModuleCode string                   `json:"module_code"`
+		RangeCodes []string                 `json:"range_codes"`
+		Field      string                   `json:"field"`
+		Rules      []map[string]interface{} `json:"rules"`
+	}
+
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+	}
+
+	if params.Field == "" {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "field cannot be empty")
+	}
+	if len(params.Rules) == 0 {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "rules cannot be empty")
+	}
+
+	infoCache, cur, _, userReq, err := cache.DecodeModuleFromCtx(ctx, params.ModuleCode)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode module from context: %w", err)
+	}
+
+	targets, err := moduleconfig.RuleTargets(cur, params.RangeCodes, params.Field)
+	if err != nil {
+		return "", err
+	}
+	sections := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if err := moduleconfig.SetRules(target.Field, params.Rules); err != nil {
+			return "", envelope.Errorf(envelope.CodeInvalidArguments, "%s[%s]: %w", target.Range.Section, params.Field, err)
+		}
+		sections = append(sections, target.Range.Section)
+	}
+
+	types := make([]string, 0, len(params.Rules))
+	for _, rule := range params.Rules {
+		types = append(types, rule["type"].(string))
+	}
+	message := fmt.Sprintf("Rules %v have been set on field '%s' in %s", types, params.Field, strings.Join(sections, ", "))
+	if err := cache.CommitModuleConfig(ctx, userReq.ConversationID, infoCache, cur, "setFieldRules", message); err != nil {
+		return "", err
+	}
+
+	return envelope.OK(message, map[string]interface{}{
+		"field":    params.Field,
+		"sections": sections,
+		"rules":    targets[0].Field.Rules,
+	})
+}
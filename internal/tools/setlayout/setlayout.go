@@ -0,0 +1,87 @@
+package setlayout
+
+import (
+	"coder/internal/cache"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// SetLayoutTool is a tool for changing the page layouts of a module
+type SetLayoutTool struct{}
+
+// NewSetLayoutTool creates a new set layout tool
+func NewSetLayoutTool() (*SetLayoutTool, error) {
+	return &SetLayoutTool{}, nil
+}
+
+// Info returns information about the tool
+func (t *SetLayoutTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	// 模块的 support 配置中使用的布局在执行时才能确定，这里只列出默认及配置的布局
+	allowed := moduleconfig.AllowedLayouts(nil)
+	return &schema.ToolInfo{
+		Name: "setLayout",
+		Desc: "Change the layout of the list page or the form pages of a module; only the configured layouts, the default ones and those the module support configuration uses are accepted",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to edit, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
+			"table": {
+				Desc:     "The layout of the list page, such as " + strings.Join(allowed["table"], ", "),
+				Type:     schema.String,
+				Required: false,
+			},
+			"form": {
+				Desc:     "The layout of the create and update pages, such as " + strings.Join(allowed["form"], ", "),
+				Type:     schema.String,
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *SetLayoutTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *SetLayoutTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	// Parse the arguments
+	var params struct {
+		ModuleCode string `json:"module_code"`
+		Table      string `json:"table"`
+		Form       string `json:"form"`
+	}
+
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+	}
+	if params.Table == "" && params.Form == "" {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "table or form must be given")
+	}
+
+	infoCache, cur, support, userReq, err := cache.DecodeModuleFromCtx(ctx, params.ModuleCode)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode module from context: %w", err)
+	}
+
+	if err := moduleconfig.SetLayout(cur, support, moduleconfig.Layout{Table: params.Table, Form: params.Form}); err != nil {
+		return "", err
+	}
+
+	message := fmt.Sprintf("Layout has been set to table '%s', form '%s'", cur.Layout.Table, cur.Layout.Form)
+	if err := cache.CommitModuleConfig(ctx, userReq.ConversationID, infoCache, cur, "setLayout", message); err != nil {
+		return "", err
+	}
+
+	return envelope.OK(message, cur.Layout)
+}
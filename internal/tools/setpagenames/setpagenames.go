@@ -0,0 +1,97 @@
+package setpagenames
+
+import (
+	"coder/internal/cache"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// SetPageNamesTool is a tool for changing the page titles of a module
+type SetPageNamesTool struct{}
+
+// NewSetPageNamesTool creates a new set page names tool
+func NewSetPageNamesTool() (*SetPageNamesTool, error) {
+	return &SetPageNamesTool{}, nil
+}
+
+// Info returns information about the tool
+func (t *SetPageNamesTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "setPageNames",
+		Desc: "Change the page titles of a module (pageName); titles that are not given stay unchanged",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to edit, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
+			"table": {
+				Desc:     "The title of the list page, such as 用户管理",
+				Type:     schema.String,
+				Required: false,
+			},
+			"new": {
+				Desc:     "The title of the create page, such as 新增用户",
+				Type:     schema.String,
+				Required: false,
+			},
+			"edit": {
+				Desc:     "The title of the update page, such as 编辑用户",
+				Type:     schema.String,
+				Required: false,
+			},
+			"name": {
+				Desc:     "The title of the detail page",
+				Type:     schema.String,
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *SetPageNamesTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *SetPageNamesTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	// Parse the arguments
+	var params struct {
+		ModuleCode string `json:"module_code"`
+		Table      string `json:"table"`
+		New        string `json:"new"`
+		Edit       string `json:"edit"`
+		Name       string `json:"name"`
+	}
+
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+	}
+	if params.Table == "" && params.New == "" && params.Edit == "" && params.Name == "" {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "at least one of table, new, edit and name must be given")
+	}
+
+	infoCache, cur, _, userReq, err := cache.DecodeModuleFromCtx(ctx, params.ModuleCode)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode module from context: %w", err)
+	}
+
+	changed := moduleconfig.SetPageNames(cur, moduleconfig.PageName{Table: params.Table, New: params.New, Edit: params.Edit, Name: params.Name})
+	if len(changed) == 0 {
+		return envelope.OK("The page names are unchanged", cur.PageName)
+	}
+
+	message := fmt.Sprintf("Page names %v have been changed", changed)
+	if err := cache.CommitModuleConfig(ctx, userReq.ConversationID, infoCache, cur, "setPageNames", message); err != nil {
+		return "", err
+	}
+
+	return envelope.OK(message, cur.PageName)
+}
@@ -0,0 +1,84 @@
+package switchmodule
+
+import (
+	"coder/api"
+	"coder/internal/cache"
+	"coder/internal/config"
+	"coder/internal/envelope"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// SwitchModuleTool is a tool for changing the active module of the conversation
+type SwitchModuleTool struct{}
+
+// NewSwitchModuleTool creates a new switch module tool
+func NewSwitchModuleTool() (*SwitchModuleTool, error) {
+	return &SwitchModuleTool{}, nil
+}
+
+// Info returns information about the tool
+func (t *SwitchModuleTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "switchModule",
+		Desc: "Make another module loaded in this conversation the active module, which the editing tools change when no module_code is given. Load modules that are not open yet with viewModule",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module to switch to",
+				Type:     schema.String,
+				Required: true,
+			},
+		}),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *SwitchModuleTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *SwitchModuleTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	var params struct {
+		ModuleCode string `json:"module_code"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+	}
+	if params.ModuleCode == "" {
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "module_code cannot be empty")
+	}
+
+	userReq, ok := ctx.Value(config.StateKey).(*api.ChatRequest)
+	if !ok {
+		return "", fmt.Errorf("state not found in context")
+	}
+
+	draft, err := cache.SwitchModule(ctx, userReq.ConversationID, params.ModuleCode)
+	if err != nil {
+		return "", err
+	}
+	ws := cache.GetWorkspace(userReq.ConversationID)
+
+	// 返回切换后模块的约束配置和最新配置，供后续编辑使用
+	return envelope.OK(fmt.Sprintf("Module '%s' (%s) is now the active module", draft.ModuleName, draft.ModuleCode), map[string]interface{}{
+		"activeModule": ws.ActiveModule,
+		"modules":      ws.Modules,
+		"moduleName":   draft.ModuleName,
+		"moduleCode":   draft.ModuleCode,
+		"support":      rawConfig(draft.Support),
+		"config":       rawConfig(draft.Cur),
+	})
+}
+
+// rawConfig embeds a module configuration as JSON, or as a string when it is not valid JSON
+func rawConfig(config string) interface{} {
+	if !json.Valid([]byte(config)) {
+		return config
+	}
+	return json.RawMessage(config)
+}
@@ -1,11 +1,9 @@
 package tools
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"log"
-	"runtime/debug"
 	"sync"
 
 	"github.com/cloudwego/eino/components/tool"
@@ -16,22 +14,47 @@ import (
 	"coder/internal/tools/addfield"
 	"coder/internal/tools/addoperation"
 	"coder/internal/tools/addsearch"
+	"coder/internal/tools/applymoduleedits"
+	"coder/internal/tools/bindoptionset"
+	"coder/internal/tools/createoptionset"
 	"coder/internal/tools/deleteaction"
 	"coder/internal/tools/deleteapi"
 	"coder/internal/tools/deletefield"
 	"coder/internal/tools/deleteoperation"
 	"coder/internal/tools/deletesearch"
+	"coder/internal/tools/diffmodule"
 	"coder/internal/tools/editaction"
 	"coder/internal/tools/editapi"
 	"coder/internal/tools/editfield"
 	"coder/internal/tools/editoperation"
+	"coder/internal/tools/editoptionset"
 	"coder/internal/tools/editsearch"
 	"coder/internal/tools/genfield"
+	"coder/internal/tools/listchanges"
+	"coder/internal/tools/mergemodule"
+	"coder/internal/tools/movefield"
+	"coder/internal/tools/patchmodule"
+	"coder/internal/tools/redochange"
+	"coder/internal/tools/removefieldrules"
 	"coder/internal/tools/saveentity"
 	"coder/internal/tools/savemodule"
+	"coder/internal/tools/setcolumns"
+	"coder/internal/tools/setfieldrules"
+	"coder/internal/tools/setlayout"
+	"coder/internal/tools/setpagenames"
+	"coder/internal/tools/switchmodule"
+	"coder/internal/tools/undolastchange"
 	"coder/internal/tools/viewmodule"
 )
 
+// ConfirmableTool is implemented by tools whose changes must be approved by the user before they run
+type ConfirmableTool interface {
+	// RequiresConfirmation reports whether the tool must be approved before running
+	RequiresConfirmation() bool
+	// Preview describes what the tool would change when run with the given arguments
+	Preview(ctx context.Context, arguments string) (interface{}, error)
+}
+
 // ToolManager 管理所有本地工具
 type ToolManager struct {
 	tools map[string]tool.BaseTool
@@ -67,6 +90,15 @@ func (tm *ToolManager) Initialize(ctx context.Context) error {
 		return fmt.Errorf("failed to register view module tool: %w", err)
 	}
 
+	// 初始化切换模块工具
+	switchModuleTool, err := switchmodule.NewSwitchModuleTool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize switch module tool: %w", err)
+	}
+	if err := tm.RegisterTool(ctx, switchModuleTool); err != nil {
+		return fmt.Errorf("failed to register switch module tool: %w", err)
+	}
+
 	// 初始化保存模块工具
 	saveModuleTool, err := savemodule.NewSaveModuleTool()
 	if err != nil {
@@ -76,6 +108,24 @@ func (tm *ToolManager) Initialize(ctx context.Context) error {
 		return fmt.Errorf("failed to register save module tool: %w", err)
 	}
 
+	// 初始化模块差异工具
+	diffModuleTool, err := diffmodule.NewDiffModuleTool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize diff module tool: %w", err)
+	}
+	if err := tm.RegisterTool(ctx, diffModuleTool); err != nil {
+		return fmt.Errorf("failed to register diff module tool: %w", err)
+	}
+
+	// 初始化合并模块工具
+	mergeModuleTool, err := mergemodule.NewMergeModuleTool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize merge module tool: %w", err)
+	}
+	if err := tm.RegisterTool(ctx, mergeModuleTool); err != nil {
+		return fmt.Errorf("failed to register merge module tool: %w", err)
+	}
+
 	// 初始化添加字段工具
 	addFieldTool, err := addfield.NewAddFieldTool()
 	if err != nil {
@@ -103,6 +153,105 @@ func (tm *ToolManager) Initialize(ctx context.Context) error {
 		return fmt.Errorf("failed to register delete field tool: %w", err)
 	}
 
+	// 初始化移动字段工具
+	moveFieldTool, err := movefield.NewMoveFieldTool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize move field tool: %w", err)
+	}
+	if err := tm.RegisterTool(ctx, moveFieldTool); err != nil {
+		return fmt.Errorf("failed to register move field tool: %w", err)
+	}
+
+	// 初始化批量修改模块工具
+	applyModuleEditsTool, err := applymoduleedits.NewApplyModuleEditsTool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize apply module edits tool: %w", err)
+	}
+	if err := tm.RegisterTool(ctx, applyModuleEditsTool); err != nil {
+		return fmt.Errorf("failed to register apply module edits tool: %w", err)
+	}
+
+	// 初始化补丁修改模块工具
+	patchModuleTool, err := patchmodule.NewPatchModuleTool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize patch module tool: %w", err)
+	}
+	if err := tm.RegisterTool(ctx, patchModuleTool); err != nil {
+		return fmt.Errorf("failed to register patch module tool: %w", err)
+	}
+
+	// 初始化设置字段校验规则工具
+	setFieldRulesTool, err := setfieldrules.NewSetFieldRulesTool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize set field rules tool: %w", err)
+	}
+	if err := tm.RegisterTool(ctx, setFieldRulesTool); err != nil {
+		return fmt.Errorf("failed to register set field rules tool: %w", err)
+	}
+
+	// 初始化移除字段校验规则工具
+	removeFieldRulesTool, err := removefieldrules.NewRemoveFieldRulesTool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize remove field rules tool: %w", err)
+	}
+	if err := tm.RegisterTool(ctx, removeFieldRulesTool); err != nil {
+		return fmt.Errorf("failed to register remove field rules tool: %w", err)
+	}
+
+	// 初始化创建选项集工具
+	createOptionSetTool, err := createoptionset.NewCreateOptionSetTool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize create option set tool: %w", err)
+	}
+	if err := tm.RegisterTool(ctx, createOptionSetTool); err != nil {
+		return fmt.Errorf("failed to register create option set tool: %w", err)
+	}
+
+	// 初始化编辑选项集工具
+	editOptionSetTool, err := editoptionset.NewEditOptionSetTool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize edit option set tool: %w", err)
+	}
+	if err := tm.RegisterTool(ctx, editOptionSetTool); err != nil {
+		return fmt.Errorf("failed to register edit option set tool: %w", err)
+	}
+
+	// 初始化绑定选项集工具
+	bindOptionSetTool, err := bindoptionset.NewBindOptionSetTool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize bind option set tool: %w", err)
+	}
+	if err := tm.RegisterTool(ctx, bindOptionSetTool); err != nil {
+		return fmt.Errorf("failed to register bind option set tool: %w", err)
+	}
+
+	// 初始化设置页面名称工具
+	setPageNamesTool, err := setpagenames.NewSetPageNamesTool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize set page names tool: %w", err)
+	}
+	if err := tm.RegisterTool(ctx, setPageNamesTool); err != nil {
+		return fmt.Errorf("failed to register set page names tool: %w", err)
+	}
+
+	// 初始化设置页面布局工具
+	setLayoutTool, err := setlayout.NewSetLayoutTool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize set layout tool: %w", err)
+	}
+	if err := tm.RegisterTool(ctx, setLayoutTool); err != nil {
+		return fmt.Errorf("failed to register set layout tool: %w", err)
+	}
+
+	// 初始化设置表单列数工具
+	setColumnsTool, err := setcolumns.NewSetColumnsTool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize set columns tool: %w", err)
+	}
+	if err := tm.RegisterTool(ctx, setColumnsTool); err != nil {
+		return fmt.Errorf("failed to register set columns tool: %w", err)
+	}
+
 	// 初始化添加搜索工具
 	addSearchTool, err := addsearch.NewAddSearchTool()
 	if err != nil {
@@ -211,6 +360,33 @@ func (tm *ToolManager) Initialize(ctx context.Context) error {
 		return fmt.Errorf("failed to register delete API tool: %w", err)
 	}
 
+	// 初始化撤销修改工具
+	undoLastChangeTool, err := undolastchange.NewUndoLastChangeTool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize undo last change tool: %w", err)
+	}
+	if err := tm.RegisterTool(ctx, undoLastChangeTool); err != nil {
+		return fmt.Errorf("failed to register undo last change tool: %w", err)
+	}
+
+	// 初始化重做修改工具
+	redoChangeTool, err := redochange.NewRedoChangeTool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize redo change tool: %w", err)
+	}
+	if err := tm.RegisterTool(ctx, redoChangeTool); err != nil {
+		return fmt.Errorf("failed to register redo change tool: %w", err)
+	}
+
+	// 初始化修改记录工具
+	listChangesTool, err := listchanges.NewListChangesTool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize list changes tool: %w", err)
+	}
+	if err := tm.RegisterTool(ctx, listChangesTool); err != nil {
+		return fmt.Errorf("failed to register list changes tool: %w", err)
+	}
+
 	// 初始化生成字段工具
 	genfieId, err := genfield.NewGenFieldTool()
 	if err != nil {
@@ -291,34 +467,25 @@ func (tm *ToolManager) GetAllToolInfos(ctx context.Context) ([]*schema.ToolInfo,
 	return toolInfos, nil
 }
 
-// ExecuteTool 执行指定的工具
-func (tm *ToolManager) ExecuteTool(ctx context.Context, toolName string, arguments string) (string, error) {
-	defer func() {
-		if r := recover(); r != nil {
-			buf := new(bytes.Buffer)
-			buf.WriteString("Recovered from panic in ExecuteTool:\n")
-			buf.WriteString(fmt.Sprintf("%v", r))
-			buf.WriteString("\n")
-			buf.WriteString(string(debug.Stack()))
-			log.Printf(buf.String())
-		}
-	}()
-
-	// 获取工具
-	tm.mu.RLock()
-	selectTools, exists := tm.tools[toolName]
-	tm.mu.RUnlock()
-
+// RequiresConfirmation 判断工具执行前是否需要用户确认
+func (tm *ToolManager) RequiresConfirmation(toolName string) bool {
+	t, exists := tm.GetToolByName(toolName)
 	if !exists {
-		return "", fmt.Errorf("tool '%s' not found", toolName)
+		return false
 	}
+	confirmable, ok := t.(ConfirmableTool)
+	return ok && confirmable.RequiresConfirmation()
+}
 
-	// 检查工具是否可调用
-	invokableTool, ok := selectTools.(tool.InvokableTool)
+// PreviewTool 生成需要确认的工具将要提交的变更
+func (tm *ToolManager) PreviewTool(ctx context.Context, toolName string, arguments string) (interface{}, error) {
+	t, exists := tm.GetToolByName(toolName)
+	if !exists {
+		return nil, fmt.Errorf("tool '%s' not found", toolName)
+	}
+	confirmable, ok := t.(ConfirmableTool)
 	if !ok {
-		return "", fmt.Errorf("tool '%s' is not invokable", toolName)
+		return nil, fmt.Errorf("tool '%s' does not support preview", toolName)
 	}
-
-	// 执行工具
-	return invokableTool.InvokableRun(ctx, arguments)
+	return confirmable.Preview(ctx, arguments)
 }
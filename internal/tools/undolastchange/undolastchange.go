@@ -0,0 +1,77 @@
+package undolastchange
+
+import (
+	"coder/api"
+	"coder/internal/cache"
+	"coder/internal/config"
+	"coder/internal/envelope"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// UndoLastChangeTool is a tool for undoing the last change made to the module draft
+type UndoLastChangeTool struct{}
+
+// NewUndoLastChangeTool creates a new undo last change tool
+func NewUndoLastChangeTool() (*UndoLastChangeTool, error) {
+	return &UndoLastChangeTool{}, nil
+}
+
+// Info returns information about the tool
+func (t *UndoLastChangeTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "undoLastChange",
+		Desc: "Undo the last change made to the module configuration, restoring the configuration before it. Use it when the user asks to revert or cancel the previous modification (e.g. 撤销刚才的修改)",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"module_code": {
+				Desc:     "The code of the open module whose last change to undo, defaults to the active module",
+				Type:     schema.String,
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+// IsInvokable indicates that this tool can be invoked
+func (t *UndoLastChangeTool) IsInvokable() bool {
+	return true
+}
+
+// InvokableRun runs the tool
+func (t *UndoLastChangeTool) InvokableRun(ctx context.Context, args string, _ ...tool.Option) (string, error) {
+	var params struct {
+		ModuleCode string `json:"module_code"`
+	}
+	if strings.TrimSpace(args) != "" {
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
+		}
+	}
+
+	userReq, ok := ctx.Value(config.StateKey).(*api.ChatRequest)
+	if !ok {
+		return "", fmt.Errorf("state not found in context")
+	}
+	key, err := cache.ResolveModuleKey(userReq.ConversationID, params.ModuleCode)
+	if err != nil {
+		return "", err
+	}
+
+	draft, revision, err := cache.UndoModuleChange(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	return envelope.OK(fmt.Sprintf("Change #%d (%s) has been undone", revision.ID, revision.Summary), map[string]interface{}{
+		"undone":    revision.ID,
+		"tool":      revision.Tool,
+		"summary":   revision.Summary,
+		"undoCount": len(draft.Undo),
+		"redoCount": len(draft.Redo),
+	})
+}
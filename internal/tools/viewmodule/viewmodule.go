@@ -2,16 +2,15 @@ package viewmodule
 
 import (
 	"coder/api"
-	"coder/app"
 	"coder/internal/cache"
 	"coder/internal/config"
+	"coder/internal/envelope"
+	"coder/internal/moduleconfig"
+	"coder/internal/moduleops"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"net/url"
 
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/schema"
@@ -30,7 +29,7 @@ func NewViewModuleTool() (*ViewModuleTool, error) {
 func (t *ViewModuleTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 	return &schema.ToolInfo{
 		Name: "viewModule",
-		Desc: "Get/Load the configuration of a module by module name and module code",
+		Desc: "Get/Load the configuration of a module by module name and module code. A module already open in this conversation returns its draft with the unsaved changes; pass reload to discard them and fetch the saved configuration again",
 		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
 			"module_name": {
 				Desc:     "The name of the module chinese name",
@@ -42,23 +41,15 @@ func (t *ViewModuleTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 				Type:     schema.String,
 				Required: true,
 			},
+			"reload": {
+				Desc:     "Discard the open draft, its unsaved changes and undo history, and fetch the module again; only when the user asks for it",
+				Type:     schema.Boolean,
+				Required: false,
+			},
 		}),
 	}, nil
 }
 
-// APIResponse is the standardized API response structure
-type APIResponse struct {
-	Code int         `json:"code"`
-	Msg  string      `json:"msg"`
-	Data interface{} `json:"data"`
-}
-
-// ModuleConfigData is the data structure for GetModuleConfig response
-type ModuleConfigData struct {
-	Support string `json:"support"`
-	Cur     string `json:"cur"`
-}
-
 // IsInvokable indicates that this tool can be invoked
 func (t *ViewModuleTool) IsInvokable() bool {
 	return true
@@ -70,14 +61,15 @@ func (t *ViewModuleTool) InvokableRun(ctx context.Context, args string, _ ...too
 	var params struct {
 		ModuleName string `json:"module_name"`
 		ModuleCode string `json:"module_code"`
+		Reload     bool   `json:"reload"`
 	}
 
 	if err := json.Unmarshal([]byte(args), &params); err != nil {
-		return "", fmt.Errorf("failed to parse arguments: %w", err)
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "failed to parse arguments: %w", err)
 	}
 
 	if params.ModuleName == "" || params.ModuleCode == "" {
-		return "", fmt.Errorf("module_name and module_code cannot be empty")
+		return "", envelope.Errorf(envelope.CodeInvalidArguments, "module_name and module_code cannot be empty")
 	}
 
 	// 获取state
@@ -87,63 +79,24 @@ func (t *ViewModuleTool) InvokableRun(ctx context.Context, args string, _ ...too
 	}
 	log.Printf("Processing LocalTool calls in message: %v", userReq)
 
-	cacheKey := cache.CacheKey(userReq.ConversationID)
-	// If not in cache, fetch from API
-	fmt.Println("Cache miss for module", params.ModuleName, params.ModuleCode, "fetching from API")
-
-	// Build the request URL with query parameters
-	log.Printf("app.ConfigClient.BaseURL: %v", app.ConfigClient.BaseURL)
-	reqURL := fmt.Sprintf("%s/dynamicForm/config", app.ConfigClient.BaseURL)
-
-	// Create a URL with query parameters
-	baseURL, err := url.Parse(reqURL)
-	if err != nil {
-		return "", fmt.Errorf("invalid URL: %w", err)
+	cacheKey := cache.ModuleKey(userReq.ConversationID, params.ModuleCode)
+	// 模块已打开时返回草稿，避免丢弃未保存的修改和撤销历史
+	open, isOpen := cache.ModuleCacheInstance.Get(cacheKey)
+	if isOpen && !params.Reload {
+		if err := cache.OpenModule(ctx, userReq.ConversationID, open); err != nil {
+			return "", err
+		}
+		message := fmt.Sprintf("Module '%s' (%s) is already open, returning the draft", open.ModuleName, open.ModuleCode)
+		if unsaved := unsavedChanges(open); len(unsaved) > 0 {
+			message += fmt.Sprintf(" with %d unsaved changes; pass reload only if the user wants to discard them", len(unsaved))
+		}
+		return envelope.OK(message, rawConfig(open.Cur))
 	}
-	log.Printf("baseURL: %v", baseURL)
-	// Add query parameters
-	query := baseURL.Query()
-	query.Add("moduleName", params.ModuleName)
-	query.Add("moduleCode", params.ModuleCode)
-	baseURL.RawQuery = query.Encode()
-	log.Printf("baseURL.String(): %v", baseURL.String())
-	// Create a new request
-	req, err := http.NewRequestWithContext(ctx, "GET", baseURL.String(), nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Send the request
-	resp, err := app.ConfigClient.Client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
 
-	// Read the response body
-	body, err := io.ReadAll(resp.Body)
+	// If not in cache, fetch from API
+	respData, err := moduleops.FetchModuleConfig(ctx, params.ModuleName, params.ModuleCode)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Parse the response
-	var apiResp APIResponse
-	apiResp.Data = &ModuleConfigData{}
-
-	fmt.Println("body", string(body))
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	// Check the response code
-	if apiResp.Code != 200 {
-		return "", fmt.Errorf("API error: %s", apiResp.Msg)
-	}
-
-	// Convert the data to the expected structure
-	respData, ok := apiResp.Data.(*ModuleConfigData)
-	if !ok {
-		return "", fmt.Errorf("unexpected response data format")
+		return "", err
 	}
 
 	cur := respData.Cur
@@ -155,11 +108,45 @@ func (t *ViewModuleTool) InvokableRun(ctx context.Context, args string, _ ...too
 		return "", fmt.Errorf("module config is empty")
 	}
 
-	// Store in cache
-	moduleCache := cache.NewModuleCacheData(params.ModuleName, params.ModuleCode, respData.Support, cur)
-	cache.ModuleCacheInstance.Set(cacheKey, moduleCache, cache.DefaultCacheExpiration)
+	// Store in cache, 打开的模块成为当前模块
+	moduleCache := cache.NewModuleCacheData(params.ModuleName, params.ModuleCode, respData.Support, respData.Cur, cur)
+	moduleCache.Version = moduleops.ConfigVersion(respData)
+	if err := cache.OpenModule(ctx, userReq.ConversationID, moduleCache); err != nil {
+		return "", err
+	}
 
-	fmt.Println("Cached module", params.ModuleName, params.ModuleCode, "with key", cacheKey)
+	// 重新加载时说明丢弃了哪些未保存的修改
+	if isOpen {
+		discarded := unsavedChanges(open)
+		return envelope.OK(fmt.Sprintf("Module '%s' (%s) has been reloaded, %d unsaved changes of the previous draft were discarded", params.ModuleName, params.ModuleCode, len(discarded)), map[string]interface{}{
+			"config":    rawConfig(cur),
+			"discarded": discarded,
+		})
+	}
 
 	return cur, nil
 }
+
+// unsavedChanges lists the changes of a draft that are not saved on the server, most recent first
+func unsavedChanges(draft *cache.ModuleCacheData) []string {
+	if moduleconfig.HashConfig(draft.Cur) == moduleconfig.HashConfig(draft.Original) {
+		return nil
+	}
+	changes := make([]string, 0, len(draft.Undo))
+	for i := len(draft.Undo) - 1; i >= 0; i-- {
+		changes = append(changes, draft.Undo[i].Summary)
+	}
+	// 没有修改记录时（如撤销历史已截断）仍说明草稿与服务器不同
+	if len(changes) == 0 {
+		changes = append(changes, "the draft differs from the saved configuration")
+	}
+	return changes
+}
+
+// rawConfig embeds a module configuration as JSON, or as a string when it is not valid JSON
+func rawConfig(config string) interface{} {
+	if !json.Valid([]byte(config)) {
+		return config
+	}
+	return json.RawMessage(config)
+}